@@ -156,6 +156,33 @@ func (m *model) dirty() {
 	m.overwrite()
 }
 
+// git init --bare in rdir, set m.remote to its path and
+// add it as origin
+func (m *model) bare(rdir string) {
+	m.remote = path.Join(rdir, m.name+".git")                              // local remote
+	os.RemoveAll(m.remote)                                                 // verify rm -rf m.remote
+	os.MkdirAll(m.remote, 0766)                                            // mkdir m.remote
+	cmd := exec.Command("git", "init", "--bare")                           // git init --bare
+	cmd.Dir = m.remote                                                     // set dir
+	cmd.Run()                                                              // run
+	cmd = exec.Command("git", "symbolic-ref", "HEAD", "refs/heads/master") // verify master
+	cmd.Dir = m.remote                                                     // set dir
+	cmd.Run()                                                              // run
+	cmd = exec.Command("git", "symbolic-ref", "HEAD", "refs/heads/master") // verify master
+	cmd.Dir = m.dir                                                        // set dir
+	cmd.Run()                                                              // run
+	cmd = exec.Command("git", "remote", "add", "origin", m.remote)         // add origin
+	cmd.Dir = m.dir                                                        // set dir
+	cmd.Run()                                                              // run
+}
+
+// git clone $m.remote
+func (m *model) local() {
+	cmd := exec.Command("git", "clone", m.remote, m.name) // git clone
+	cmd.Dir = m.dir                                       // set dir
+	cmd.Run()                                             // run
+}
+
 // hub delete -y $m.remote
 func (m *model) remove() {
 	cmd := exec.Command("hub", "delete", "-y", m.remote) // hub delete
@@ -192,6 +219,35 @@ func (ms models) startup(mdir string, tdir string) {
 	wg.Wait()
 }
 
+// create model repos with local remotes in rdir, configured
+// to their names
+func (ms models) local(mdir string, tdir string, rdir string) {
+	var wg sync.WaitGroup
+	for i := range ms {
+		wg.Add(1)
+		go func(m *model) {
+			defer wg.Done()
+			m.set(mdir)                // set models dir
+			m.mkdirf()                 // make directory fresh
+			m.init()                   // git init
+			m.bare(rdir)               // create local remote
+			m.create("README")         // create readme
+			m.add()                    // add *
+			m.commit("Initial commit") // commit -m "Initial commit"
+			m.push()                   // push -u origin master
+			m.direct(tdir)             // set tmpgis dir
+			m.local()                  // clone to tmpgis dir
+			m.set(mdir)                // set models dir
+			m.behind()                 // set *Behind* models behind origin master
+			m.set(tdir)                // switch to tmpgis directory
+			m.ahead()                  // set *Ahead* models behind origin master
+			m.untracked()              // make *Untracked* models untracked
+			m.dirty()                  // make *Dirty* models dirty
+		}(ms[i])
+	}
+	wg.Wait()
+}
+
 // remove all remotes
 func (ms models) cleanup() {
 	var wg sync.WaitGroup
@@ -205,6 +261,24 @@ func (ms models) cleanup() {
 	wg.Wait()
 }
 
+// only returns models with names in names, creating
+// models for names that aren't in ms
+func (ms models) only(names []string) (oms models) {
+	for _, name := range names {
+		m := new(model)
+		m.name = name
+
+		for _, mm := range ms {
+			if mm.name == name {
+				m = mm
+			}
+		}
+
+		oms = append(oms, m)
+	}
+	return oms
+}
+
 // create models from set options
 func modeler(user string) (ms models) {
 
@@ -284,6 +358,14 @@ func paths(pkg string) (string, string) {
 	return base, dir
 }
 
+// identity sets a Git author and committer for the test run
+func identity() {
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		os.Setenv(k+"_NAME", "git-in-sync")
+		os.Setenv(k+"_EMAIL", "git-in-sync@localhost")
+	}
+}
+
 // create subdirectories
 func subdirs(dir string) (mdir string, tdir string) {
 	mdir = path.Join(dir, "models")
@@ -381,6 +463,45 @@ var Jmap = map[string][]byte{
 		}]
 	}
 	`),
+	"settings": []byte(`
+	{
+		"pull": "ff-only",
+		"scan": {"patterns": ["INTERNAL-[0-9]+"], "max-size": 10},
+		"hooks": {"post-clone": "make bootstrap", "pre-push": "make test"},
+		"bundles": [{
+			"path": "SETPATH",
+			"zones": [{
+					"user": "jychri",
+					"remote": "github",
+					"workspace": "go",
+					"pull": "merge",
+					"message": "chore: sync {repo} on {hostname}",
+					"convention": "conventional",
+					"repositories": [
+						{
+							"name": "git-in-sync",
+							"pull": "rebase",
+							"branch": "develop",
+							"remotes": {"upstream": "https://github.com/jychri/git-in-sync"},
+							"hooks": {"pre-push": "go test ./..."}
+						},
+						"brf"
+					]
+				},
+				{
+					"user": "jychri",
+					"remote": "github",
+					"workspace": "main",
+					"clone": {"depth": 1, "filter": "blobless", "sparse": ["docs"]},
+					"author": {"name": "jychri", "email": "jychri@home.org", "signing-key": "~/.ssh/id_ed25519.pub", "format": "ssh"},
+					"repositories": [
+						"tilde"
+					]
+				}
+			]
+		}]
+	}
+	`),
 }
 
 // Rmap maps a string to Results.
@@ -403,6 +524,10 @@ var Rmap = map[string]Results{
 			"gis-UntrackedBehind",
 			"gis-Complete",
 		}}},
+	"settings": {
+		{"jychri", "github", "go", []string{"git-in-sync", "brf"}},
+		{"jychri", "github", "main", []string{"tilde"}},
+	},
 }
 
 // Result is the expected value for a zone.
@@ -453,6 +578,26 @@ func Hub(scope string, key string) (string, func()) {
 	}
 }
 
+// Local creates a test environment at ~/tmpgis/$scope like Hub,
+// but with bare repositories in ~/tmpgis/$scope/remotes standing
+// in for GitHub. Local returns the path of ~/tmpgis/$scope, where
+// the repos are cloned to ./tmpgis and their models to ./models,
+// and a cleanup function.
+func Local(scope string, names ...string) (string, func()) {
+	base, dir := paths(scope)         // base and directory paths
+	mdir, tdir := subdirs(dir)        // create subdirectories models and tmp
+	rdir := path.Join(dir, "remotes") // local remotes
+	identity()                        // set author and committer
+	ms := modeler("local")            // create basic models, collect as models
+	ms = ms.only(names)               // keep models matching names
+	os.MkdirAll(rdir, 0777)           // mkdir remotes
+	ms.local(mdir, tdir, rdir)        // async startup
+
+	return dir, func() {
+		os.RemoveAll(base) // rm -rf base
+	}
+}
+
 // Direct verifies ~/.gisrc.json, but does not validate its content.
 // If no ~/.gisrc.json, Direct creates one and returns its absolute
 // path with a cleanup function. If present, Direct returns its
@@ -2,11 +2,16 @@
 package conf
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"regexp"
+	"strings"
 
 	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/tilde"
 )
 
 // private
@@ -27,6 +32,260 @@ func read(f flags.Flags) []byte {
 	return bs
 }
 
+// validate calls log.Fatalf() if c has settings
+// with unknown values.
+func validate(c Config) {
+	pulls := []string{c.Pull}
+	origins := []string{c.Origin}
+	subs := []string{c.Submodules}
+	convs := []string{c.Convention}
+
+	for _, bl := range c.Bundles {
+		for _, z := range bl.Zones {
+			pulls = append(pulls, z.Pull)
+			origins = append(origins, z.Origin)
+			subs = append(subs, z.Submodules)
+			convs = append(convs, z.Convention)
+			for _, r := range z.Repos {
+				pulls = append(pulls, r.Pull)
+				origins = append(origins, r.Origin)
+				subs = append(subs, r.Submodules)
+			}
+		}
+	}
+
+	for _, p := range pulls {
+		switch p {
+		case "", "ff-only", "rebase", "merge":
+		default:
+			log.Fatalf("Unknown pull strategy (%v)", p)
+		}
+	}
+
+	for _, o := range origins {
+		switch o {
+		case "", "ask", "remote", "config":
+		default:
+			log.Fatalf("Unknown origin policy (%v)", o)
+		}
+	}
+
+	for _, s := range subs {
+		switch s {
+		case "", "recurse", "off":
+		default:
+			log.Fatalf("Unknown submodules setting (%v)", s)
+		}
+	}
+
+	hooks := []map[string]string{c.Hooks}
+
+	for _, bl := range c.Bundles {
+		for _, z := range bl.Zones {
+			hooks = append(hooks, z.Hooks)
+			for _, r := range z.Repos {
+				hooks = append(hooks, r.Hooks)
+			}
+		}
+	}
+
+	for _, h := range hooks {
+		for ev := range h {
+			switch ev {
+			case "post-clone", "pre-pull", "post-pull", "pre-commit", "post-commit", "pre-push", "post-push":
+			default:
+				log.Fatalf("Unknown hook event (%v)", ev)
+			}
+		}
+	}
+
+	for _, a := range authors(c) {
+		switch a.Format {
+		case "", "openpgp", "ssh", "x509":
+		default:
+			log.Fatalf("Unknown signing format (%v)", a.Format)
+		}
+	}
+
+	for _, cv := range convs {
+		switch cv {
+		case "", "conventional", "free":
+		default:
+			log.Fatalf("Unknown commit convention (%v)", cv)
+		}
+	}
+
+	if c.Scan != nil {
+		for _, p := range c.Scan.Patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				log.Fatalf("Can't compile scan pattern (%v)", p)
+			}
+		}
+	}
+
+	for _, bl := range c.Bundles {
+		for _, z := range bl.Zones {
+			for _, cl := range append([]*Clone{z.Clone}, clones(z.Repos)...) {
+				if cl == nil {
+					continue
+				}
+
+				switch {
+				case cl.Depth < 0:
+					log.Fatalf("Negative clone depth (%v)", cl.Depth)
+				case cl.Filter != "" && cl.Filter != "blobless" && cl.Filter != "treeless":
+					log.Fatalf("Unknown clone filter (%v)", cl.Filter)
+				}
+			}
+		}
+	}
+}
+
+// skip reads the next value from dec, including nested values.
+func skip(dec *json.Decoder) error {
+	depth := 0
+
+	for {
+		tk, err := dec.Token()
+
+		if err != nil {
+			return err
+		}
+
+		switch tk {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// span returns the offsets in bs of the value at path, where
+// path holds object keys and array indexes, e.g. "bundles", 0.
+// ok is false if there's no value at path.
+func span(bs []byte, path ...interface{}) (start int, end int, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+
+	for _, p := range path {
+		tk, err := dec.Token()
+
+		if err != nil {
+			return 0, 0, false
+		}
+
+		found := false
+
+		switch p := p.(type) {
+		case string:
+			if tk != json.Delim('{') {
+				return 0, 0, false
+			}
+
+			for !found && dec.More() {
+				if k, err := dec.Token(); err != nil {
+					return 0, 0, false
+				} else if k == p {
+					found = true
+				} else if skip(dec) != nil {
+					return 0, 0, false
+				}
+			}
+		case int:
+			if tk != json.Delim('[') {
+				return 0, 0, false
+			}
+
+			for i := 0; !found && dec.More(); i++ {
+				if i == p {
+					found = true
+				} else if skip(dec) != nil {
+					return 0, 0, false
+				}
+			}
+		}
+
+		if !found {
+			return 0, 0, false
+		}
+	}
+
+	// the value follows whitespace, ':' after a key or ',' after an element
+	start = int(dec.InputOffset())
+
+	for start < len(bs) && strings.ContainsRune(" \t\r\n:,", rune(bs[start])) {
+		start++
+	}
+
+	if skip(dec) != nil {
+		return 0, 0, false
+	}
+
+	return start, int(dec.InputOffset()), true
+}
+
+// insert returns bs with the member s inserted first in the object
+// starting at offset o, following the object's own whitespace.
+func insert(bs []byte, o int, s string) []byte {
+	i := o + 1
+
+	for i < len(bs) && strings.ContainsRune(" \t\r\n", rune(bs[i])) {
+		i++
+	}
+
+	ws := string(bs[o+1 : i])
+
+	if ws == "" {
+		ws = " "
+	}
+
+	if bs[i] != '}' {
+		s += ","
+	}
+
+	return splice(bs, i, i, s+ws)
+}
+
+// splice returns bs with bs[start:end] replaced by s.
+func splice(bs []byte, start int, end int, s string) []byte {
+	return append(append(append([]byte(nil), bs[:start]...), s...), bs[end:]...)
+}
+
+// quote returns s as a JSON string.
+func quote(s string) string {
+	bs, _ := json.Marshal(s)
+	return string(bs)
+}
+
+// authors returns the Authors in c.
+func authors(c Config) (as []*Author) {
+	if c.Author != nil {
+		as = append(as, c.Author)
+	}
+
+	for _, bl := range c.Bundles {
+		for _, z := range bl.Zones {
+			if z.Author != nil {
+				as = append(as, z.Author)
+			}
+		}
+	}
+
+	return as
+}
+
+// clones returns the Clone settings of rs.
+func clones(rs []Repo) (cls []*Clone) {
+	for _, r := range rs {
+		cls = append(cls, r.Clone)
+	}
+	return cls
+}
+
 // unmarsmall unmarhalls the contents of a gisrc.json file,
 // read to a byte slice by read, and returns a Config and
 // quit.Out. In a normal run quit.Err will call log.Fatalf()
@@ -46,16 +305,182 @@ func unmarshal(bs []byte, f flags.Flags) Config {
 // Public
 
 // Config holds unmrashalled JSON from a gisrc.json file.
+// Settings in Config apply to every Zone and Repo.
 type Config struct {
-	Bundles []struct {
-		Path  string `json:"path"`
-		Zones []struct {
-			User      string   `json:"user"`
-			Remote    string   `json:"remote"`
-			Workspace string   `json:"workspace"`
-			Repos     []string `json:"repositories"`
-		} `json:"zones"`
-	} `json:"bundles"`
+	Pull       string            `json:"pull,omitempty"`       // "ff-only", "rebase" or "merge"
+	Origin     string            `json:"origin,omitempty"`     // "ask", "remote" or "config"
+	Submodules string            `json:"submodules,omitempty"` // "recurse" or "off"
+	Scan       *Scan             `json:"scan,omitempty"`
+	Message    string            `json:"message,omitempty"`    // commit message template, "sync {repo} on {hostname}"
+	Convention string            `json:"convention,omitempty"` // "conventional" to require conventional commits
+	Author     *Author           `json:"author,omitempty"`
+	Hooks      map[string]string `json:"hooks,omitempty"` // shell commands by event, "post-clone": "make bootstrap"
+	Bundles    []Bundle          `json:"bundles"`
+}
+
+// Author holds the identity and signing key for commits. An
+// Author in a Zone replaces the Author in Config.
+type Author struct {
+	Name       string `json:"name,omitempty"`
+	Email      string `json:"email,omitempty"`
+	SigningKey string `json:"signing-key,omitempty"` // GPG key ID or SSH key path, "" to not sign
+	Format     string `json:"format,omitempty"`      // "openpgp", "ssh" or "x509", "" for "openpgp"
+}
+
+// Scan holds settings for the scan that blocks commits
+// with secrets or large files.
+type Scan struct {
+	Patterns []string `json:"patterns,omitempty"` // regexes for secrets, besides the defaults
+	MaxSize  int      `json:"max-size,omitempty"` // size limit in megabytes, 0 for 50
+}
+
+// Bundle holds the Zones in a path.
+type Bundle struct {
+	Path  string `json:"path"`
+	Zones []Zone `json:"zones"`
+}
+
+// Zone holds the Repos in a workspace. Settings in
+// Zone override those in Config.
+type Zone struct {
+	User       string            `json:"user"`
+	Remote     string            `json:"remote"`
+	Workspace  string            `json:"workspace"`
+	Pull       string            `json:"pull,omitempty"`
+	Origin     string            `json:"origin,omitempty"`
+	Submodules string            `json:"submodules,omitempty"`
+	Clone      *Clone            `json:"clone,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Convention string            `json:"convention,omitempty"`
+	Author     *Author           `json:"author,omitempty"`
+	Hooks      map[string]string `json:"hooks,omitempty"`
+	Repos      []Repo            `json:"repositories"`
+}
+
+// Repo holds a repository name and its settings, which
+// override those in Zone and Config. In a gisrc.json file
+// a Repo is either a name, "git-in-sync", or an object,
+// {"name": "git-in-sync", "pull": "rebase", "branch": "main"}.
+// Remotes maps remote names to URLs. A Remote named "origin"
+// replaces the URL built from User and Remote. Origin sets
+// how a mismatched origin URL is repaired: "ask", "remote" to
+// rewrite the checkout or "config" to rewrite gisrc.json.
+// Submodules "recurse" clones and updates submodules.
+type Repo struct {
+	Name       string            `json:"name"`
+	Pull       string            `json:"pull,omitempty"`
+	Branch     string            `json:"branch,omitempty"` // expected branch, "" for the remote's HEAD
+	Remotes    map[string]string `json:"remotes,omitempty"`
+	Origin     string            `json:"origin,omitempty"`
+	Submodules string            `json:"submodules,omitempty"` // "recurse" to clone and update submodules
+	Clone      *Clone            `json:"clone,omitempty"`
+	Hooks      map[string]string `json:"hooks,omitempty"`
+}
+
+// Clone holds settings for cloning a Repo. A Clone in a Repo
+// replaces the Clone in its Zone.
+type Clone struct {
+	Depth        int      `json:"depth,omitempty"`         // --depth, 0 for full history
+	SingleBranch bool     `json:"single-branch,omitempty"` // --single-branch
+	Filter       string   `json:"filter,omitempty"`        // "blobless" or "treeless"
+	Sparse       []string `json:"sparse,omitempty"`        // sparse-checkout directories
+}
+
+// UnmarshalJSON unmarshals a Repo from a name or an object.
+func (r *Repo) UnmarshalJSON(bs []byte) error {
+	if err := json.Unmarshal(bs, &r.Name); err == nil {
+		return nil
+	}
+
+	type repo Repo // without UnmarshalJSON
+	return json.Unmarshal(bs, (*repo)(r))
+}
+
+// Names returns the names of the Repos in z.
+func (z Zone) Names() (ns []string) {
+	for _, r := range z.Repos {
+		ns = append(ns, r.Name)
+	}
+	return ns
+}
+
+// Pick returns the first setting in ss that isn't empty,
+// e.g. Pick(repo, zone, config, default).
+func Pick(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// SetOrigin edits gisrc.json at f.Config so that the repo name
+// in workspace of bundle uses url as its origin. Only the repo's
+// entry is changed, the rest of the file is written as it was.
+func SetOrigin(f flags.Flags, bundle string, workspace string, name string, url string) error {
+	c := Init(f)
+	bs := read(f)
+
+	for i, bl := range c.Bundles {
+		if tilde.Abs(bl.Path) != bundle {
+			continue
+		}
+
+		for j, z := range bl.Zones {
+			if z.Workspace != workspace {
+				continue
+			}
+
+			for k, r := range z.Repos {
+				if r.Name != name {
+					continue
+				}
+
+				p := []interface{}{"bundles", i, "zones", j, "repositories", k}
+				s, e, ok := span(bs, p...)
+
+				if !ok {
+					return fmt.Errorf("can't find %v in %v", name, f.Config)
+				}
+
+				o := `"origin": ` + quote(url)                              // origin member
+				rs, _, rok := span(bs, append(p, "remotes")...)             // remotes object
+				ost, oe, ook := span(bs, append(p, "remotes", "origin")...) // origin value
+
+				switch {
+				case bs[s] == '"': // a name, "tilde"
+					bs = splice(bs, s, e, fmt.Sprintf(`{"name": %v, "remotes": {%v}}`, quote(name), o))
+				case ook:
+					bs = splice(bs, ost, oe, quote(url))
+				case rok && bs[rs] == '{':
+					bs = insert(bs, rs, o)
+				case rok:
+					return fmt.Errorf("%v remotes isn't an object in %v", name, f.Config)
+				default:
+					bs = insert(bs, s, `"remotes": {`+o+"}")
+				}
+
+				return ioutil.WriteFile(f.Config, bs, 0644)
+			}
+		}
+	}
+
+	return fmt.Errorf("%v not found in %v", name, f.Config)
+}
+
+// Hooks merges hooks by event, e.g. Hooks(repo, zone, config),
+// where a hook in an earlier map replaces one in a later map.
+func Hooks(hs ...map[string]string) map[string]string {
+	m := make(map[string]string)
+
+	for i := len(hs) - 1; i >= 0; i-- {
+		for ev, sh := range hs[i] {
+			m[ev] = sh
+		}
+	}
+
+	return m
 }
 
 // Init returns unmarshalled data from gisrc.json.
@@ -64,5 +489,6 @@ type Config struct {
 func Init(f flags.Flags) (c Config) {
 	bs := read(f)        // read the file at path f.Config
 	c = unmarshal(bs, f) // unmarshal the data from file at path f.Config
+	validate(c)          // validate settings
 	return c
 }
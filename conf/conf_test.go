@@ -1,11 +1,14 @@
 package conf
 
 import (
+	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jychri/git-in-sync/atp"
 	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/tilde"
 )
 
 func TestInit(t *testing.T) {
@@ -15,6 +18,7 @@ func TestInit(t *testing.T) {
 	}{
 		{"conf", "recipes"},
 		{"conf", "tmpgis"},
+		{"conf", "settings"},
 	} {
 		p, cleanup := atp.Setup(tr.pkg, tr.recipe)
 		f := flags.Testing(p)
@@ -39,10 +43,119 @@ func TestInit(t *testing.T) {
 				t.Errorf("Init: (%v != %v)", rs[i].Workspace, zs[i].Workspace)
 			}
 
-			if !reflect.DeepEqual(rs[i].Repos, zs[i].Repos) {
-				t.Errorf("Init: (%v != %v)", rs[i].Repos, zs[i].Repos)
+			if !reflect.DeepEqual(rs[i].Repos, zs[i].Names()) {
+				t.Errorf("Init: (%v != %v)", rs[i].Repos, zs[i].Names())
 			}
 
 		}
 	}
 }
+
+func TestSettings(t *testing.T) {
+	p, cleanup := atp.Setup("conf-settings", "settings")
+	f := flags.Testing(p)
+	c := Init(f)
+	want := map[string]string{
+		"git-in-sync": "rebase",  // repo
+		"brf":         "merge",   // zone
+		"tilde":       "ff-only", // config
+	}
+
+	defer cleanup()
+
+	if c.Scan == nil || c.Scan.MaxSize != 10 || len(c.Scan.Patterns) != 1 {
+		t.Errorf("Settings: scan (%v)", c.Scan)
+	}
+
+	if z := c.Bundles[0].Zones[0]; z.Message == "" || z.Convention != "conventional" {
+		t.Errorf("Settings: %v message (%v, %v)", z.Workspace, z.Message, z.Convention)
+	}
+
+	for _, z := range c.Bundles[0].Zones {
+		for _, r := range z.Repos {
+			if got := Pick(r.Pull, z.Pull, c.Pull); got != want[r.Name] {
+				t.Errorf("Settings: %v pull (%v != %v)", r.Name, got, want[r.Name])
+			}
+
+			if r.Name == "git-in-sync" && r.Branch != "develop" {
+				t.Errorf("Settings: %v branch (%v != develop)", r.Name, r.Branch)
+			}
+
+			if hs := Hooks(r.Hooks, z.Hooks, c.Hooks); hs["post-clone"] != "make bootstrap" {
+				t.Errorf("Settings: %v hooks (%v)", r.Name, hs)
+			}
+
+			if hs := Hooks(r.Hooks, z.Hooks, c.Hooks); r.Name == "git-in-sync" && hs["pre-push"] != "go test ./..." {
+				t.Errorf("Settings: %v hooks (%v)", r.Name, hs)
+			}
+
+			if r.Name == "git-in-sync" && r.Remotes["upstream"] == "" {
+				t.Errorf("Settings: %v remotes (%v)", r.Name, r.Remotes)
+			}
+
+			if r.Name == "tilde" && (z.Author == nil || z.Author.Format != "ssh") {
+				t.Errorf("Settings: %v author (%v)", r.Name, z.Author)
+			}
+
+			if r.Name == "tilde" && (z.Clone == nil || z.Clone.Depth != 1 || z.Clone.Filter != "blobless") {
+				t.Errorf("Settings: %v clone (%v)", r.Name, z.Clone)
+			}
+		}
+	}
+}
+
+func TestSetOrigin(t *testing.T) {
+	p, cleanup := atp.Setup("conf-origin", "settings")
+	f := flags.Testing(p)
+	c := Init(f)
+	bp := tilde.Abs(c.Bundles[0].Path)
+	url := "https://github.com/jychri/tilde-renamed"
+	gurl := "https://github.com/jychri/git-in-sync-renamed"
+
+	defer cleanup()
+
+	before, _ := ioutil.ReadFile(p)
+
+	if err := SetOrigin(f, bp, "main", "tilde", url); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _ := ioutil.ReadFile(p)
+	want := strings.Replace(string(before), `"tilde"`, `{"name": "tilde", "remotes": {"origin": "`+url+`"}}`, 1)
+
+	if string(after) != want {
+		t.Errorf("SetOrigin: changed more than tilde\n%s", after)
+	}
+
+	// added to, then replaced in, existing remotes
+	for _, u := range []string{url, gurl} {
+		if err := SetOrigin(f, bp, "go", "git-in-sync", u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := SetOrigin(f, bp, "main", "missing", url); err == nil {
+		t.Errorf("SetOrigin: missing repo not reported")
+	}
+
+	c = Init(f)
+
+	for _, z := range c.Bundles[0].Zones {
+		for _, r := range z.Repos {
+			switch r.Name {
+			case "tilde":
+				if r.Remotes["origin"] != url {
+					t.Errorf("SetOrigin: %v remotes (%v)", r.Name, r.Remotes)
+				}
+			case "git-in-sync":
+				if r.Pull != "rebase" || r.Remotes["upstream"] == "" || r.Remotes["origin"] != gurl {
+					t.Errorf("SetOrigin: %v settings lost (%v)", r.Name, r)
+				}
+			}
+		}
+	}
+
+	if bs, _ := ioutil.ReadFile(p); !strings.Contains(string(bs), `"brf"`) {
+		t.Errorf("SetOrigin: brf isn't written as a name")
+	}
+}
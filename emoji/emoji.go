@@ -20,6 +20,7 @@ var em = map[string]int{
 	"Briefcase":            128188,
 	"BuildingConstruction": 127959,
 	"Bunny":                128048,
+	"Bust":                 128100,
 	"Checkmark":            9989,
 	"Clapper":              127916,
 	"Clipboard":            128203,
@@ -32,12 +33,17 @@ var em = map[string]int{
 	"FileCabinet":          128452,
 	"Flag":                 127937,
 	"FlagInHole":           9971,
+	"Floppy":               128190,
 	"Fire":                 128293,
 	"Folder":               128193,
+	"Fork":                 127860,
 	"Glasses":              128083,
+	"Herb":                 127807,
 	"Hole":                 128371,
+	"Hook":                 129693,
 	"Hourglass":            9203,
 	"Inbox":                128229,
+	"Key":                  128273,
 	"Microscope":           128300,
 	"Memo":                 128221,
 	"Outbox":               128228,
@@ -54,6 +60,7 @@ var em = map[string]int{
 	"Ship":                 128674,
 	"Sheep":                128017,
 	"Squirrel":             128063,
+	"Stop":                 128721,
 	"Telescope":            128301,
 	"Text":                 128172,
 	"ThumbsUp":             128077,
@@ -63,6 +70,7 @@ var em = map[string]int{
 	"Turtle":               128034,
 	"Unicorn":              129412,
 	"Warning":              128679,
+	"Wrench":               128295,
 }
 
 // convert returns an emoji character as a string value.
@@ -58,6 +58,14 @@ var tm = map[int]string{
 	128452: "🗄",
 	128048: "🐰",
 	128678: "🚦",
+	128273: "🔑",
+	127807: "🌿",
+	127860: "🍴",
+	128295: "🔧",
+	128190: "💾",
+	128721: "🛑",
+	128100: "👤",
+	129693: "🪝",
 }
 
 // func TestPrint(t *testing.T) {
@@ -2,27 +2,80 @@
 package flags
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/jychri/tilde"
 )
 
-// Flags records values for Mode and Config.
+// Flags records values for Mode, Config, Git timeouts and options.
 type Flags struct {
 	Mode   string
 	Config string
+	Fetch  time.Duration // timeout for fetch and pull
+	Clone  time.Duration // timeout for clone
+	Push   time.Duration // timeout for push
+
+	Branches bool // true to sync all local branches, not just HEAD
+	Editor   bool // true to write commit messages in $EDITOR
+
+	Workspaces []string // workspaces to include, nil for all
+	Repos      []string // repo names or patterns to include, nil for all
+	Jobs       int      // repos to work on at once, 0 for all
+	Output     string   // exec output, "grouped" or "prefixed"
+	Days       int      // days without commits before a branch is stale
+	Command    string   // subcommand, "exec", "grep", "branch" etc., "" to sync
+	Args       []string // arguments after the subcommand
+}
+
+// default timeouts
+const (
+	fetch = 2 * time.Minute
+	clone = 10 * time.Minute
+	push  = 2 * time.Minute
+)
+
+// default days before a branch is stale
+const days = 90
+
+// subcommands, "gis [flags] exec make test", "gis grep -n TODO"
+var commands = map[string]bool{
+	"exec":        true,
+	"grep":        true,
+	"files":       true,
+	"branch":      true,
+	"tag":         true,
+	"stale":       true,
+	"stash":       true,
+	"maintenance": true,
 }
 
 // Init returns validated user input as Flags.
 func Init() (f Flags) {
 
-	var c, m string
+	var c, m, ws, rs, o string
+	var fe, cl, pu time.Duration
+	var br, ed bool
+	var j, d int
 
 	flag.StringVar(&m, "m", "verify", "mode")
 	flag.StringVar(&c, "c", "~/.gisrc.json", "configuration")
+	flag.DurationVar(&fe, "fetch", fetch, "fetch and pull timeout")
+	flag.DurationVar(&cl, "clone", clone, "clone timeout")
+	flag.DurationVar(&pu, "push", push, "push timeout")
+	flag.BoolVar(&br, "branches", false, "sync all local branches")
+	flag.BoolVar(&ed, "editor", false, "write commit messages in $EDITOR")
+	flag.StringVar(&ws, "w", "", "workspaces, comma separated")
+	flag.StringVar(&rs, "r", "", "repos or patterns, comma separated")
+	flag.IntVar(&j, "j", 0, "repos to work on at once, 0 for all")
+	flag.StringVar(&o, "output", "grouped", "exec output, grouped or prefixed")
+	flag.IntVar(&d, "days", days, "days without commits before a branch is stale, 0 to ignore age")
 	flag.Parse()
 
 	switch m {
@@ -31,6 +84,27 @@ func Init() (f Flags) {
 		m = "verify"
 	}
 
+	switch o {
+	case "grouped", "prefixed":
+	default:
+		o = "grouped"
+	}
+
+	if j < 0 {
+		j = 0
+	}
+
+	if d < 0 {
+		d = 0
+	}
+
+	var cmd string
+	args := flag.Args()
+
+	if len(args) >= 1 && commands[args[0]] {
+		cmd, args = args[0], args[1:]
+	}
+
 	if env := os.Getenv("MODE"); env == "TESTING" {
 		m = "testing"
 	}
@@ -41,12 +115,46 @@ func Init() (f Flags) {
 
 	c = tilde.Abs(c)
 
-	return Flags{Mode: m, Config: c}
+	return Flags{
+		Mode: m, Config: c, Fetch: fe, Clone: cl, Push: pu, Branches: br, Editor: ed,
+		Workspaces: list(ws), Repos: list(rs), Jobs: j, Output: o, Days: d,
+		Command: cmd, Args: args,
+	}
+}
+
+// list splits a comma separated list, returning nil if s is empty.
+func list(s string) (ss []string) {
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			ss = append(ss, e)
+		}
+	}
+	return ss
+}
+
+// Selected returns true if workspace and name pass the
+// -w and -r filters. Repos match names or patterns, "gis-*".
+func (f Flags) Selected(workspace string, name string) bool {
+	return match(f.Workspaces, workspace) && match(f.Repos, name)
+}
+
+// match returns true if ps is empty or s matches one of ps.
+func match(ps []string, s string) bool {
+	if len(ps) == 0 {
+		return true
+	}
+
+	for _, p := range ps {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Testing returns a Flags instance with Mode == "testing".
 func Testing(c string) Flags {
-	return Flags{Mode: "testing", Config: c}
+	return Flags{Mode: "testing", Config: c, Fetch: fetch, Clone: clone, Push: push, Output: "grouped", Days: days}
 }
 
 // ClearScreen clears the screen.
@@ -74,6 +182,61 @@ func Printv(f Flags, s string, z ...interface{}) string {
 	return out
 }
 
+// Printr redraws the current line of standard output if not
+// running in 'oneline' or 'testing' mode. An empty s clears it.
+func Printr(f Flags, s string, z ...interface{}) string {
+	out := fmt.Sprintf(s, z...)
+
+	switch f.Mode {
+	case "oneline":
+	case "testing":
+	default:
+		fmt.Printf("\r\033[K%v", out)
+	}
+	return out
+}
+
+// Confirm prints a question and reads a yes or no answer from
+// standard input. Confirm returns false without asking if running
+// in 'oneline' or 'testing' mode.
+func Confirm(f Flags, s string, z ...interface{}) bool {
+
+	switch f.Mode {
+	case "oneline", "testing":
+		return false
+	}
+
+	fmt.Printf(s, z...)
+	rdr := bufio.NewReader(os.Stdin)
+	in, err := rdr.ReadString('\n')
+
+	if err != nil {
+		return false
+	}
+
+	switch strings.TrimSpace(in) {
+	case "please", "y", "ye", "yes", "ys", "1", "ok", "sure":
+		return true
+	default:
+		return false
+	}
+}
+
+// Ask prints a question and returns the trimmed answer, or ""
+// in 'oneline' or 'testing' mode.
+func Ask(f Flags, s string, z ...interface{}) string {
+
+	switch f.Mode {
+	case "oneline", "testing":
+		return ""
+	}
+
+	fmt.Printf(s, z...)
+	rdr := bufio.NewReader(os.Stdin)
+	in, _ := rdr.ReadString('\n')
+	return strings.TrimSpace(in)
+}
+
 // Login returns true if f.Mode == "login".
 func (f Flags) Login() bool {
 	if f.Mode == "login" {
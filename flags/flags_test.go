@@ -42,4 +42,30 @@ func TestAll(t *testing.T) {
 	if got != want {
 		t.Errorf("Flags: want: %v, got %v\n", got, want)
 	}
+
+	if b := Confirm(f, "%v?", "continue"); b != false {
+		t.Errorf("Flags: want: false, got %v\n", b)
+	}
+}
+
+func TestSelected(t *testing.T) {
+	f := Flags{Workspaces: list("go, main"), Repos: list("gis-*,tilde")}
+
+	for _, tr := range []struct {
+		workspace, name string
+		want            bool
+	}{
+		{"go", "gis-Ahead", true},
+		{"main", "tilde", true},
+		{"go", "brf", false},
+		{"other", "tilde", false},
+	} {
+		if got := f.Selected(tr.workspace, tr.name); got != tr.want {
+			t.Errorf("Selected: %v/%v (%v != %v)", tr.workspace, tr.name, got, tr.want)
+		}
+	}
+
+	if f := (Flags{}); !f.Selected("go", "brf") {
+		t.Errorf("Selected: empty filters excluded go/brf")
+	}
 }
@@ -2,6 +2,11 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/jychri/timer"
 
 	"github.com/jychri/git-in-sync/conf"
@@ -40,9 +45,80 @@ func Init() (f flags.Flags, rs repos.Repos, st *stat.Stat, ti *timer.Timer) {
 	return f, rs, st, ti                                                 // return
 }
 
+// interrupt returns a Context that is canceled on the first
+// interrupt. A second interrupt exits immediately.
+func interrupt(f flags.Flags) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sig                                                            // first interrupt
+		es := emoji.Get("Slash")                                         // Slash emoji
+		flags.Printv(f, "%v canceling, interrupt again to exit now", es) // print canceling
+		cancel()                                                         // cancel ctx
+		<-sig                                                            // second interrupt
+		os.Exit(130)                                                     // exit now
+	}()
+
+	return ctx
+}
+
 func main() {
-	f, rs, st, t := Init()        // init Flags, Repos, Stat and a Timer
+	f, rs, st, t := Init() // init Flags, Repos, Stat and a Timer
+	ctx := interrupt(f)    // cancel on interrupt
+
+	if f.Command == "exec" {
+		if rs.Exec(ctx, f, t) >= 1 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if f.Command == "grep" || f.Command == "files" {
+		os.Exit(rs.Search(ctx, f, t))
+	}
+
 	rs.VerifyWorkspaces(f, st, t) // verify workspaces, create if needed
-	rs.VerifyRepos(f, st, t)      // verify repos, clone if needed (async)
-	rs.VerifyChanges(f, st, t)    // verify and submit changes (async)
+
+	if f.Command == "maintenance" {
+		if rs.Maintain(ctx, f, st, t) >= 1 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	rs.VerifyRepos(ctx, f, st, t) // verify repos, clone if needed (async)
+
+	if rs.Canceled(ctx, f, t) {
+		os.Exit(130)
+	}
+
+	if f.Command == "branch" || f.Command == "tag" {
+		if rs.Refs(ctx, f, t) >= 1 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if f.Command == "stale" {
+		if rs.Stale(ctx, f, t) >= 1 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if f.Command == "stash" {
+		if rs.Stashes(ctx, f, t) >= 1 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	rs.VerifyChanges(ctx, f, st, t) // verify and submit changes (async)
+	rs.VerifyBranches(ctx, f, t)    // sync other local branches (async)
+
+	if rs.Canceled(ctx, f, t) {
+		os.Exit(130)
+	}
 }
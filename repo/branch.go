@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// Branch models a local branch with an upstream.
+type Branch struct {
+	Name     string // "feature"
+	Upstream string // `%(upstream)`, "refs/remotes/origin/feature"
+	Remote   string // `%(upstream:remotename)`, "origin"
+	Merge    string // `%(upstream:remoteref)`, "refs/heads/feature"
+	Ahead    int    // commits on Name, but not Upstream
+	Behind   int    // commits on Upstream, but not Name
+	Gone     bool   // true if Upstream no longer exists
+	Head     bool   // true if Name is checked out
+}
+
+// String returns the state of b, "feature ahead 2, behind 1".
+func (b Branch) String() string {
+	var ss []string
+
+	if b.Ahead >= 1 {
+		ss = append(ss, fmt.Sprintf("ahead %v", b.Ahead))
+	}
+
+	if b.Behind >= 1 {
+		ss = append(ss, fmt.Sprintf("behind %v", b.Behind))
+	}
+
+	if b.Gone {
+		ss = append(ss, "gone")
+	}
+
+	if len(ss) == 0 {
+		return b.Name
+	}
+
+	return strings.Join([]string{b.Name, strings.Join(ss, ", ")}, " ")
+}
+
+// track returns the ahead and behind counts and gone state
+// from `%(upstream:track,nobracket)`, "ahead 1, behind 2".
+func track(s string) (ahead int, behind int, gone bool) {
+
+	if s == "gone" {
+		return 0, 0, true
+	}
+
+	if m := regexp.MustCompile(`ahead (\d+)`).FindStringSubmatch(s); len(m) == 2 {
+		ahead, _ = strconv.Atoi(m[1])
+	}
+
+	if m := regexp.MustCompile(`behind (\d+)`).FindStringSubmatch(s); len(m) == 2 {
+		behind, _ = strconv.Atoi(m[1])
+	}
+
+	return ahead, behind, false
+}
+
+// GitBranches records every local branch with an upstream in r.Branches.
+func (r *Repo) GitBranches(ctx context.Context) {
+	const dsc = "GitBranches"
+
+	if !r.Verified {
+		return
+	}
+
+	fs := []string{
+		"%(refname:short)",
+		"%(upstream)",
+		"%(upstream:remotename)",
+		"%(upstream:remoteref)",
+		"%(upstream:track,nobracket)",
+		"%(HEAD)",
+	}
+
+	format := strings.Join([]string{"--format=", strings.Join(fs, "%09")}, "")
+	args := []string{r.GitDir, "for-each-ref", format, "refs/heads"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	r.Branches = nil
+
+	for _, l := range strings.Split(out, "\n") {
+		fs := strings.Split(l, "\t")
+
+		// skip branches without an upstream
+		if len(fs) != 6 || fs[1] == "" {
+			continue
+		}
+
+		b := Branch{Name: fs[0], Upstream: fs[1], Remote: fs[2], Merge: fs[3]}
+		b.Ahead, b.Behind, b.Gone = track(fs[4])
+		b.Head = fs[5] == "*"
+		r.Branches = append(r.Branches, b)
+	}
+}
+
+// Stray returns the branches that aren't checked out and
+// are ahead, behind or gone.
+func (r *Repo) Stray() (bs []Branch) {
+	for _, b := range r.Branches {
+		if !b.Head && (b.Ahead >= 1 || b.Behind >= 1 || b.Gone) {
+			bs = append(bs, b)
+		}
+	}
+	return bs
+}
+
+// GitFastForward fast-forwards branch b to its upstream without
+// checking it out. The update is refused unless it's a fast-forward.
+func (r *Repo) GitFastForward(ctx context.Context, f flags.Flags, b Branch) bool {
+	const dsc = "GitFastForward"                                                  // description
+	eb := emoji.Get("Boat")                                                       // Boat emoji
+	rn := r.Name                                                                  // repo name
+	flags.Printv(f, "%v %v fast-forwarding %v to %v", eb, rn, b.Name, b.Upstream) // print
+	rs := strings.Join([]string{b.Upstream, ":refs/heads/", b.Name}, "")          // refspec
+	args := []string{"-C", r.RepoPath, "fetch", ".", rs}                          // arguments
+	return r.gitP(ctx, args, dsc)                                                 // command
+}
+
+// GitPushBranch pushes branch b to its upstream.
+func (r *Repo) GitPushBranch(ctx context.Context, f flags.Flags, b Branch) bool {
+	const dsc = "GitPushBranch"                                           // description
+	er := emoji.Get("Rocket")                                             // Rocket emoji
+	rn := r.Name                                                          // repo name
+	flags.Printv(f, "%v %v pushing %v to %v", er, rn, b.Name, b.Remote)   // print
+	ctx, cancel := context.WithTimeout(ctx, f.Push)                       // push timeout
+	defer cancel()                                                        //
+	rs := strings.Join([]string{"refs/heads/", b.Name, ":", b.Merge}, "") // refspec
+	args := []string{"-C", r.RepoPath, "push", b.Remote, rs}              // arguments
+	return r.gitP(ctx, args, dsc)                                         // command
+}
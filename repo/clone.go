@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// attempts is the number of times a clone is tried before
+// a transient failure is recorded.
+const attempts = 3
+
+// backoff is the wait before the first retry, doubled for
+// each retry after it.
+var backoff = 2 * time.Second
+
+// rxp matches a progress line, "Receiving objects:  45% (450/1000)".
+var rxp = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z ]+):\s+(\d+)%`)
+
+// transient returns true if em reports a failure that may
+// pass if the command is run again.
+func transient(em string) bool {
+	for _, s := range []string{
+		"Could not resolve host",
+		"Connection timed out",
+		"Connection reset",
+		"Connection refused",
+		"Failed to connect",
+		"Operation timed out",
+		"The remote end hung up unexpectedly",
+		"unexpected disconnect",
+		"early EOF",
+		"RPC failed",
+		"TLS connection was non-properly terminated",
+		"HTTP 5",
+	} {
+		if strings.Contains(em, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// progress records the progress git writes to standard error
+// with --progress, keeping other lines as the error message.
+// progress is written by a clone and read by the display.
+type progress struct {
+	mu      sync.Mutex
+	attempt int      // current attempt, from 1
+	state   string   // "Receiving objects 45%" or "waiting"
+	done    bool     // true once the clone has finished
+	buf     []byte   // an unfinished line
+	lines   []string // lines that aren't progress
+}
+
+// Write splits bs on the carriage returns and newlines
+// git uses to redraw progress.
+func (p *progress) Write(bs []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, bs...)
+
+	for {
+		i := bytes.IndexAny(p.buf, "\r\n")
+
+		if i < 0 {
+			break
+		}
+
+		l := string(p.buf[:i])
+		p.buf = p.buf[i+1:]
+
+		switch m := rxp.FindStringSubmatch(l); {
+		case m != nil:
+			p.state = fmt.Sprintf("%v %v%%", m[1], m[2])
+		case strings.TrimSpace(l) != "":
+			p.lines = append(p.lines, l)
+		}
+	}
+
+	return len(bs), nil
+}
+
+// start resets p for attempt a.
+func (p *progress) start(a int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempt = a
+	p.state = ""
+	p.done = false
+	p.buf = nil
+	p.lines = nil
+}
+
+// set records s as the state of p.
+func (p *progress) set(s string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = s
+}
+
+// finish marks p as done.
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+}
+
+// message returns the lines that aren't progress, and any
+// unfinished line.
+func (p *progress) message() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ls := p.lines
+
+	if l := strings.TrimSpace(string(p.buf)); l != "" {
+		ls = append(ls, l)
+	}
+
+	return strings.Join(ls, "\n")
+}
+
+// String returns the state of p, "retry 2, Receiving objects 45%",
+// or "" if p is done or hasn't started.
+func (p *progress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done || p.attempt == 0 {
+		return ""
+	}
+
+	s := p.state
+
+	if s == "" {
+		s = "starting"
+	}
+
+	if p.attempt >= 2 {
+		s = fmt.Sprintf("retry %v, %v", p.attempt, s)
+	}
+
+	return s
+}
+
+// Progress returns the state of a running clone, "Receiving
+// objects 45%", or "" if r isn't cloning.
+func (r *Repo) Progress() string {
+	return r.clone.String()
+}
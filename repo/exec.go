@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Exec runs sh with "sh -c" in r.RepoPath, writing its stdout and
+// stderr to w, and returns its exit code. Exec returns -1 and an
+// error if r isn't cloned, or sh couldn't start or was canceled.
+func (r *Repo) Exec(ctx context.Context, sh string, w io.Writer) (int, error) {
+
+	if _, err := os.Stat(r.GitPath); err != nil {
+		return -1, errors.New("not cloned")
+	}
+
+	err := r.shell(ctx, sh, "exec", w)
+
+	if m := expired(ctx); m != "" {
+		return -1, errors.New(m)
+	}
+
+	if ee, ok := err.(*exec.ExitError); ok {
+		return ee.ExitCode(), nil
+	}
+
+	if err != nil {
+		return -1, err
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// search runs a Git command in r.RepoPath that prints paths at
+// the start of each line, and returns the lines with r.Name
+// prefixed to each path. A command that exits 1 without an
+// error, like a search without matches, returns no lines.
+func (r *Repo) search(ctx context.Context, args []string) (ls []string, err error) {
+
+	if _, err := os.Stat(r.GitPath); err != nil {
+		return nil, errors.New("not cloned")
+	}
+
+	ctx, cancel := deadline(ctx)
+	defer cancel()
+
+	var outb, errb bytes.Buffer
+
+	cmd := r.command(append([]string{"-C", r.RepoPath}, args...))
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	err = run(ctx, cmd)
+
+	if m := expired(ctx); m != "" {
+		return nil, errors.New(m)
+	}
+
+	if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() == 1 && errb.Len() == 0 {
+		return nil, nil
+	}
+
+	if err != nil {
+		if em := strings.TrimSpace(errb.String()); em != "" {
+			return nil, errors.New(em)
+		}
+		return nil, err
+	}
+
+	out := strings.TrimSuffix(outb.String(), "\n")
+
+	if out == "" {
+		return nil, nil
+	}
+
+	for _, l := range strings.Split(out, "\n") {
+
+		// context separators, "git grep -C 2", and breaks, "--break"
+		if l == "--" || l == "" {
+			ls = append(ls, l)
+			continue
+		}
+
+		ls = append(ls, r.Name+"/"+l)
+	}
+
+	return ls, nil
+}
+
+// unprefixed returns the first of args that makes "git grep"
+// print lines that don't start with a path, or "".
+func unprefixed(args []string) string {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+
+		switch {
+		case a == "--":
+			return ""
+		case a == "--heading", a == "--no-filename", a == "-O", strings.HasPrefix(a, "--open-files-in-pager"):
+			return a
+		case strings.HasPrefix(a, "--"), !strings.HasPrefix(a, "-"):
+			continue
+		}
+
+		// short options, "-hn", where e, f, A, B, C and m take a value
+		for j, c := range a[1:] {
+			if c == 'h' || c == 'O' {
+				return a
+			}
+
+			if strings.ContainsRune("efABCm", c) {
+				if j == len(a)-2 {
+					i++ // the value is the next argument
+				}
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// GitGrep runs "git grep" with args in r.RepoPath, returning the
+// matches in tracked files with r.Name prefixed to each path,
+// "gis-Ahead/main.go:12:func main() {". Options that print lines
+// without a path, "--heading" and "-h", return an error.
+func (r *Repo) GitGrep(ctx context.Context, args []string) ([]string, error) {
+	if err := GrepArgs(args); err != nil {
+		return nil, err
+	}
+
+	return r.search(ctx, append([]string{"grep", "--no-color"}, args...))
+}
+
+// GrepArgs returns an error if args for "git grep" print lines
+// without a path, which can't be prefixed with a repo.
+func GrepArgs(args []string) error {
+	if a := unprefixed(args); a != "" {
+		return fmt.Errorf("%v isn't supported, paths are prefixed with the repo", a)
+	}
+	return nil
+}
+
+// GitLsFiles returns the tracked files in r.RepoPath matching
+// pathspecs ps, "*.proto", with r.Name prefixed to each path.
+func (r *Repo) GitLsFiles(ctx context.Context, ps []string) ([]string, error) {
+	return r.search(ctx, append([]string{"ls-files", "--"}, ps...))
+}
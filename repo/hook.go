@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// shell runs sh with "sh -c" in r.RepoPath, writing its
+// stdout and stderr to w.
+func (r *Repo) shell(ctx context.Context, sh string, event string, w io.Writer) error {
+	cmd := exec.Command("sh", "-c", sh)
+	cmd.Dir = r.RepoPath
+	cmd.Stdout = w
+	cmd.Stderr = w
+	cmd.Env = append(os.Environ(),
+		"GIS_REPO="+r.Name,
+		"GIS_WORKSPACE="+r.Workspace,
+		"GIS_PATH="+r.RepoPath,
+		"GIS_BRANCH="+r.LocalBranch,
+		"GIS_STATUS="+r.Status,
+		"GIS_EVENT="+event,
+	)
+
+	return run(ctx, cmd)
+}
+
+// Hook runs the shell command in r.Hooks for event in r.RepoPath,
+// with GIS_REPO, GIS_WORKSPACE, GIS_PATH, GIS_BRANCH, GIS_STATUS
+// and GIS_EVENT set. A failing "pre-" hook records its output as
+// an error, marks r Skipped and returns false. A failing "post-"
+// hook records its output as a warning and returns true, as its
+// action has already run. Hook returns true if there's no hook.
+func (r *Repo) Hook(ctx context.Context, f flags.Flags, event string) bool {
+	const dsc = "Hook"
+
+	sh, ok := r.Hooks[event]
+
+	if !ok || sh == "" {
+		return true
+	}
+
+	if r.Verified == false {
+		return false
+	}
+
+	eh := emoji.Get("Hook")                                // Hook emoji
+	flags.Printv(f, "%v %v %v: %v", eh, r.Name, event, sh) // print
+
+	var b bytes.Buffer
+
+	if err := r.shell(ctx, sh, event, &b); err == nil {
+		return true
+	}
+
+	out := strings.TrimSpace(b.String())
+
+	if m := expired(ctx); m != "" {
+		out = m
+	}
+
+	if strings.HasPrefix(event, "pre-") {
+		r.Error(dsc, fmt.Sprintf("fatal: %v hook failed (%v)", event, out))
+		r.Category = "Skipped"
+		return false
+	}
+
+	r.Error(dsc, fmt.Sprintf("warning: %v hook failed (%v)", event, out))
+	return true
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jychri/tilde"
+)
+
+// identity returns the git options that commit as r.AuthorName
+// and r.AuthorEmail and sign with r.SigningKey, where set.
+func (r *Repo) identity() (args []string) {
+	if r.AuthorName != "" {
+		args = append(args, "-c", "user.name="+r.AuthorName)
+	}
+
+	if r.AuthorEmail != "" {
+		args = append(args, "-c", "user.email="+r.AuthorEmail)
+	}
+
+	if r.SigningKey == "" {
+		return args
+	}
+
+	key := r.SigningKey
+
+	// ssh keys may be paths, "~/.ssh/id_ed25519.pub"
+	if r.SigningFormat == "ssh" && len(key) >= 1 && key[0] == '~' {
+		key = tilde.Abs(key)
+	}
+
+	if r.SigningFormat != "" {
+		args = append(args, "-c", "gpg.format="+r.SigningFormat)
+	}
+
+	return append(args, "-c", "user.signingkey="+key, "-c", "commit.gpgsign=true")
+}
+
+// GitIdentity records the identity git uses for commits in r,
+// from its config, and sets r.IdentityMismatch if it differs
+// from r.AuthorName or r.AuthorEmail.
+func (r *Repo) GitIdentity(ctx context.Context) {
+
+	if !r.Verified || (r.AuthorName == "" && r.AuthorEmail == "") {
+		return
+	}
+
+	r.IdentityName, _ = r.git(ctx, []string{"-C", r.RepoPath, "config", "user.name"})
+	r.IdentityEmail, _ = r.git(ctx, []string{"-C", r.RepoPath, "config", "user.email"})
+
+	switch {
+	case r.AuthorName != "" && r.AuthorName != r.IdentityName:
+		r.IdentityMismatch = true
+	case r.AuthorEmail != "" && r.AuthorEmail != r.IdentityEmail:
+		r.IdentityMismatch = true
+	}
+}
+
+// Identities returns the identity in r's config and in its
+// zone, "Jane <jane@home.org>" and "Jane <jane@work.com>".
+func (r *Repo) Identities() (string, string) {
+	return fmt.Sprintf("%v <%v>", r.IdentityName, r.IdentityEmail),
+		fmt.Sprintf("%v <%v>", r.AuthorName, r.AuthorEmail)
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+var lfsOnce sync.Once
+var lfsFound bool
+
+// lfs returns true if the git-lfs extension is installed.
+func lfs() bool {
+	lfsOnce.Do(func() {
+		_, err := exec.LookPath("git-lfs")
+		lfsFound = err == nil
+	})
+	return lfsFound
+}
+
+// pointers returns the paths in `git lfs ls-files` output,
+// "oid - path", whose objects are missing, marked '-' rather
+// than '*'.
+func pointers(out string) (ps []string) {
+	for _, l := range strings.Split(out, "\n") {
+		if fs := strings.SplitN(l, " ", 3); len(fs) == 3 && fs[1] == "-" {
+			ps = append(ps, fs[2])
+		}
+	}
+	return ps
+}
+
+// lfsAttributes returns true if a .gitattributes file at any
+// depth, or .git/info/attributes, routes files through the lfs
+// filter. Tracked and untracked .gitattributes files are read.
+func (r *Repo) lfsAttributes(ctx context.Context) bool {
+	args := []string{"-C", r.RepoPath, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", ":(glob)**/.gitattributes"}
+	out, _ := r.git(ctx, args)
+	ps := []string{path.Join(r.GitPath, "info", "attributes")}
+
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			ps = append(ps, path.Join(r.RepoPath, p))
+		}
+	}
+
+	for _, p := range ps {
+		if bs, err := ioutil.ReadFile(p); err == nil && strings.Contains(string(bs), "filter=lfs") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GitLFS sets r.LFS if gitattributes route files through the lfs
+// filter and records files whose LFS objects are missing in
+// r.LFSMissing. Repos that use LFS fail verification if the
+// git-lfs extension isn't installed, as their pulls would leave
+// pointer files and their pushes would leave objects behind.
+func (r *Repo) GitLFS(ctx context.Context) {
+	const dsc = "GitLFS"
+
+	if !r.Verified {
+		return
+	}
+
+	if !r.lfsAttributes(ctx) {
+		return
+	}
+
+	r.LFS = true
+
+	if !lfs() {
+		r.Error(dsc, "fatal: git-lfs is not installed")
+		return
+	}
+
+	args := []string{"-C", r.RepoPath, "lfs", "ls-files"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	r.LFSMissing = pointers(out)
+}
+
+// GitLFSPull downloads and checks out the LFS objects of the
+// checked out branch. GitLFSPull returns true without running
+// if r doesn't use LFS.
+func (r *Repo) GitLFSPull(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitLFSPull"
+
+	if !r.LFS {
+		return true
+	}
+
+	ef := emoji.Get("Floppy")                                   // Floppy emoji
+	rn := r.Name                                                // repo name
+	flags.Printv(f, "%v %v pulling LFS objects", ef, rn)        // print
+	ctx, cancel := context.WithTimeout(ctx, f.Fetch)            // fetch timeout
+	defer cancel()                                              //
+	args := []string{"-C", r.RepoPath, "lfs", "pull", "origin"} // arguments
+	return r.gitP(ctx, args, dsc)                               // command
+}
+
+// GitLFSPush uploads the LFS objects of the checked out branch
+// before it's pushed, rather than relying on a pre-push hook.
+// GitLFSPush returns true without running if r doesn't use LFS.
+func (r *Repo) GitLFSPush(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitLFSPush"
+
+	if !r.LFS {
+		return true
+	}
+
+	ef := emoji.Get("Floppy")                                       // Floppy emoji
+	rn := r.Name                                                    // repo name
+	lb := r.LocalBranch                                             // local branch
+	flags.Printv(f, "%v %v pushing LFS objects", ef, rn)            // print
+	ctx, cancel := context.WithTimeout(ctx, f.Push)                 // push timeout
+	defer cancel()                                                  //
+	args := []string{"-C", r.RepoPath, "lfs", "push", "origin", lb} // arguments
+	return r.gitP(ctx, args, dsc)                                   // command
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// private
+
+// size returns n bytes as "512KB", "12.3MB" or "1.2GB".
+func size(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%vKB", n>>10)
+}
+
+// objects returns the bytes used by loose objects, packs and
+// garbage in r, from "git count-objects -v".
+func (r *Repo) objects(ctx context.Context) (n int64) {
+	out, _ := r.git(ctx, []string{"-C", r.RepoPath, "count-objects", "-v"})
+
+	for _, l := range strings.Split(out, "\n") {
+		fs := strings.SplitN(l, ": ", 2)
+
+		if len(fs) != 2 {
+			continue
+		}
+
+		switch fs[0] {
+		case "size", "size-pack", "size-garbage":
+			kb, _ := strconv.ParseInt(fs[1], 10, 64)
+			n += kb << 10
+		}
+	}
+
+	return n
+}
+
+// corrupt returns the lines of fsck output that report
+// missing, broken or corrupt objects.
+func corrupt(s string) (ls []string) {
+	for _, l := range strings.Split(s, "\n") {
+		for _, w := range []string{"error", "fatal", "missing", "broken", "corrupt", "bad "} {
+			if strings.Contains(l, w) {
+				ls = append(ls, strings.TrimSpace(l))
+				break
+			}
+		}
+	}
+	return ls
+}
+
+// Public
+
+// Maintenance records the results of GitMaintain.
+type Maintenance struct {
+	Before  int64    // bytes in .git/objects before
+	After   int64    // bytes in .git/objects after
+	Corrupt []string // objects fsck reported
+	Failed  []string // tasks that failed, "prune (fatal: ...)"
+}
+
+// String returns m as "12.3MB -> 10.1MB".
+func (m Maintenance) String() string {
+	return fmt.Sprintf("%v -> %v", size(m.Before), size(m.After))
+}
+
+// GitMaintain checks r with fsck, then prunes stale origin
+// branches, repacks with gc and writes the commit-graph.
+// Housekeeping is skipped if fsck finds corruption, so that gc
+// can't prune objects that might still be recovered, or if fsck
+// times out, which is recorded in m.Failed rather than m.Corrupt.
+func (r *Repo) GitMaintain(ctx context.Context, f flags.Flags) (m Maintenance) {
+
+	if !r.Verified {
+		return m
+	}
+
+	ew := emoji.Get("Wrench")                        // Wrench emoji
+	flags.Printv(f, "%v %v maintaining", ew, r.Name) // print
+
+	m.Before = r.objects(ctx)
+	m.After = m.Before
+
+	cctx, ccancel := context.WithTimeout(ctx, f.Clone)                           // clone timeout, for large repos
+	defer ccancel()                                                              //
+	args := []string{"-C", r.RepoPath, "fsck", "--no-progress", "--no-dangling"} // arguments
+	out, em := r.git(cctx, args)                                                 // command
+
+	if x := expired(cctx); x != "" {
+		m.Failed = append(m.Failed, fmt.Sprintf("fsck (%v)", x))
+		return m
+	}
+
+	if m.Corrupt = corrupt(out + "\n" + em); len(m.Corrupt) >= 1 {
+		return m
+	}
+
+	fctx, fcancel := context.WithTimeout(ctx, f.Fetch) // fetch timeout
+	defer fcancel()                                    //
+	gctx, gcancel := context.WithTimeout(ctx, f.Clone) // clone timeout, for large repos
+	defer gcancel()                                    //
+
+	tasks := []struct {
+		name string
+		ctx  context.Context
+		args []string
+	}{
+		{"prune", fctx, []string{"remote", "prune", "origin"}},
+		{"gc", gctx, []string{"gc", "--quiet"}},
+		{"commit-graph", gctx, []string{"commit-graph", "write", "--reachable", "--no-progress"}},
+	}
+
+	for _, t := range tasks {
+		args := append([]string{"-C", r.RepoPath}, t.args...)
+
+		if _, em := r.git(t.ctx, args); em != "" {
+			m.Failed = append(m.Failed, fmt.Sprintf("%v (%v)", t.name, em))
+		}
+	}
+
+	m.After = r.objects(ctx)
+	return m
+}
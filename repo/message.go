@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jychri/brf"
+)
+
+// rxcc matches the first line of a conventional commit message,
+// "feat(conf): add commit templates".
+var rxcc = regexp.MustCompile(`^(build|chore|ci|docs|feat|fix|perf|refactor|revert|style|test)(\([\w./-]+\))?!?: \S`)
+
+// conventional returns true if m is a conventional commit message.
+func conventional(m string) bool {
+	return rxcc.MatchString(m)
+}
+
+// Expand returns s with its placeholders replaced: {repo},
+// {workspace}, {branch}, {hostname}, {date}, {files} and {count}.
+func (r *Repo) Expand(s string) string {
+	cs := r.Changes
+
+	if r.Staged != nil {
+		cs = r.Staged
+	}
+
+	hn, _ := os.Hostname()
+
+	return strings.NewReplacer(
+		"{repo}", r.Name,
+		"{workspace}", r.Workspace,
+		"{branch}", r.LocalBranch,
+		"{hostname}", hn,
+		"{date}", time.Now().Format("2006-01-02"),
+		"{files}", brf.Summary(cs, 60),
+		"{count}", strconv.Itoa(len(cs)),
+	).Replace(s)
+}
+
+// template returns the text an editor opens with for a commit
+// message, with the state of r in comment lines.
+func (r *Repo) template() string {
+	var b bytes.Buffer
+
+	cs := r.Changes
+
+	if r.Staged != nil {
+		cs = r.Staged
+	}
+
+	ub := r.UpstreamBranch
+
+	if ub == "" {
+		ub = "no upstream"
+	}
+
+	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, "# Please enter the commit message for %v. Lines starting\n", r.Name)
+	fmt.Fprintf(&b, "# with '#' will be ignored, and an empty message aborts the commit.\n")
+	fmt.Fprintf(&b, "#\n")
+	fmt.Fprintf(&b, "# Repo:      %v {%v}\n", r.Name, r.Workspace)
+	fmt.Fprintf(&b, "# Branch:    %v -> %v\n", r.LocalBranch, ub)
+
+	if len(cs) >= 1 {
+		fmt.Fprintf(&b, "# Staged:    [%v]{%v}\n", len(cs), brf.Summary(cs, 60))
+	}
+
+	if r.ShortStat != "" {
+		fmt.Fprintf(&b, "# Changed:   %v (%v)\n", r.ShortStat, r.ShortStatSummary)
+	}
+
+	if len(r.UntrackedFiles) >= 1 {
+		fmt.Fprintf(&b, "# Untracked: [%v]{%v}\n", len(r.UntrackedFiles), brf.Summary(r.UntrackedFiles, 60))
+	}
+
+	return b.String()
+}
+
+// editor returns the editor git would use for a commit message,
+// from GIT_EDITOR, core.editor, VISUAL or EDITOR.
+func (r *Repo) editor() string {
+	cmd := exec.Command("git", "-C", r.RepoPath, "var", "GIT_EDITOR")
+	out, err := cmd.Output()
+
+	if err != nil {
+		return "vi"
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// stripspace removes comment lines and surrounding whitespace
+// from s, as `git commit` does.
+func stripspace(s string) string {
+	cmd := exec.Command("git", "stripspace", "--strip-comments")
+	cmd.Stdin = strings.NewReader(s)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// edit opens the user's editor on a template in the repo's
+// .git directory and returns the message saved, without
+// comment lines. An empty message means the commit is aborted.
+func (r *Repo) edit() (string, error) {
+	p := path.Join(r.GitPath, "GIS_EDITMSG")
+
+	if err := ioutil.WriteFile(p, []byte(r.template()), 0644); err != nil {
+		return "", err
+	}
+
+	defer os.Remove(p)
+
+	// the editor may have arguments, "code --wait"
+	cmd := exec.Command("sh", "-c", r.editor()+` "$@"`, "sh", p)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	bs, err := ioutil.ReadFile(p)
+
+	if err != nil {
+		return "", err
+	}
+
+	return stripspace(string(bs)), nil
+}
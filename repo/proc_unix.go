@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package repo
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setpgid starts cmd in a new process group.
+func setpgid(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// kill kills the process group started by cmd, or just
+// its process if cmd wasn't started in a new group.
+func kill(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package repo
+
+import (
+	"os/exec"
+)
+
+// setpgid is a no-op on Windows.
+func setpgid(cmd *exec.Cmd) {}
+
+// kill kills the process started by cmd.
+func kill(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	cmd.Process.Kill()
+}
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// private
+
+// ref returns the full name of branch or tag name,
+// "refs/heads/release-1.2" or "refs/tags/v1.2.0".
+func ref(kind string, name string) string {
+	if kind == "tag" {
+		return "refs/tags/" + name
+	}
+	return "refs/heads/" + name
+}
+
+// local returns true if ref rf exists in r.
+func (r *Repo) local(rf string) bool {
+	_, ok := r.quiet([]string{"-C", r.RepoPath, "rev-parse", "-q", "--verify", rf})
+	return ok
+}
+
+// remote returns true if ref rf exists on origin, or an error
+// if origin can't be reached.
+func (r *Repo) remote(ctx context.Context, f flags.Flags, rf string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.Fetch)
+	defer cancel()
+
+	var outb, errb bytes.Buffer
+
+	cmd := r.command([]string{"-C", r.RepoPath, "ls-remote", "origin", rf})
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	if err := run(ctx, cmd); err != nil {
+		em := strings.TrimSuffix(errb.String(), "\n")
+
+		if m := expired(ctx); m != "" {
+			em = m
+		}
+
+		if em == "" {
+			em = "fatal: " + err.Error()
+		}
+
+		return false, errors.New(em)
+	}
+
+	return outb.Len() > 0, nil
+}
+
+// Public
+
+// ValidRef returns true if name is a valid branch or tag name.
+func ValidRef(kind string, name string) bool {
+	return exec.Command("git", "check-ref-format", ref(kind, name)).Run() == nil
+}
+
+// Preflight returns the reason r can't take a new branch or tag
+// name, or "" if it can. r must be verified, Complete, on its
+// expected branch and not have name already, locally or on origin.
+func (r *Repo) Preflight(ctx context.Context, f flags.Flags, kind string, name string) string {
+	rf := ref(kind, name)
+
+	switch {
+	case !r.Verified:
+		return "not verified"
+	case r.expected() == "":
+		return "default branch unknown"
+	case r.LocalBranch != r.expected():
+		return fmt.Sprintf("on %v, not %v", r.LocalBranch, r.expected())
+	case r.Category != "Complete":
+		return fmt.Sprintf("not complete (%v)", strings.ToLower(r.Status))
+	case r.local(rf):
+		return fmt.Sprintf("%v %v exists", kind, name)
+	}
+
+	ok, err := r.remote(ctx, f, rf)
+
+	switch {
+	case err != nil:
+		return fmt.Sprintf("origin unreachable (%v)", err)
+	case ok:
+		return fmt.Sprintf("%v %v exists on origin", kind, name)
+	}
+
+	return ""
+}
+
+// GitCreateRef creates branch or annotated tag name at HEAD and
+// pushes it to origin. Tags are signed if r.SigningKey is set,
+// with message m.
+func (r *Repo) GitCreateRef(ctx context.Context, f flags.Flags, kind string, name string, m string) bool {
+	const dsc = "GitCreateRef"                                  // description
+	eb := emoji.Get("Fork")                                     // Fork emoji
+	rn := r.Name                                                // repo name
+	flags.Printv(f, "%v %v creating %v %v", eb, rn, kind, name) // print
+
+	args := []string{"-C", r.RepoPath, "branch", name}
+
+	if kind == "tag" {
+		sa := "-a" // annotate
+
+		if r.SigningKey != "" {
+			sa = "-s" // sign
+		}
+
+		args = append(r.identity(), "-C", r.RepoPath, "tag", sa, name, "-m", m)
+	}
+
+	if !r.gitP(ctx, args, dsc) {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.Push)                    // push timeout
+	defer cancel()                                                     //
+	rf := ref(kind, name)                                              // refspec
+	args = []string{"-C", r.RepoPath, "push", "origin", rf + ":" + rf} // arguments
+	return r.gitP(ctx, args, dsc)                                      // command
+}
+
+// GitDeleteRef deletes branch or tag name from origin and r.
+// Refs that are already gone are skipped, but r fails if
+// origin can't be reached.
+func (r *Repo) GitDeleteRef(ctx context.Context, f flags.Flags, kind string, name string) bool {
+	const dsc = "GitDeleteRef"                                  // description
+	es := emoji.Get("Slash")                                    // Slash emoji
+	rn := r.Name                                                // repo name
+	flags.Printv(f, "%v %v deleting %v %v", es, rn, kind, name) // print
+	rf := ref(kind, name)                                       // full ref
+
+	ok, err := r.remote(ctx, f, rf)
+
+	if err != nil {
+		r.Error(dsc, err.Error())
+		r.Verified = false
+		r.Category = "Skipped"
+		return false
+	}
+
+	if ok {
+		pctx, cancel := context.WithTimeout(ctx, f.Push)
+		defer cancel()
+
+		if !r.gitP(pctx, []string{"-C", r.RepoPath, "push", "origin", "--delete", rf}, dsc) {
+			return false
+		}
+	}
+
+	if !r.local(rf) {
+		return r.Verified
+	}
+
+	args := []string{"-C", r.RepoPath, "branch", "-D", name}
+
+	if kind == "tag" {
+		args = []string{"-C", r.RepoPath, "tag", "-d", name}
+	}
+
+	return r.gitP(ctx, args, dsc)
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Remote models a named remote besides origin, e.g. the
+// canonical project that origin was forked from.
+type Remote struct {
+	Name string // "upstream"
+	URL  string // "https://github.com/golang/go"
+	Lag  int    // commits on Name's default branch, but not origin's
+}
+
+// String returns the lag of rm, "upstream behind 3".
+func (rm Remote) String() string {
+	return fmt.Sprintf("%v behind %v", rm.Name, rm.Lag)
+}
+
+// SetRemotes records the remotes in m, mapping names to URLs.
+// A remote named "origin" replaces r.URL.
+func (r *Repo) SetRemotes(m map[string]string) {
+	r.Remotes = nil
+
+	for name, url := range m {
+		if name == "origin" {
+			r.URL = url
+			continue
+		}
+		r.Remotes = append(r.Remotes, Remote{Name: name, URL: url})
+	}
+
+	sort.Slice(r.Remotes, func(i, j int) bool { return r.Remotes[i].Name < r.Remotes[j].Name })
+}
+
+// GitAddRemotes adds the remotes in r.Remotes that are missing
+// from the repository, and records an error if a remote's URL
+// doesn't match.
+func (r *Repo) GitAddRemotes(ctx context.Context) {
+	const dsc = "GitAddRemotes"
+
+	if !r.Verified {
+		return
+	}
+
+	for _, rm := range r.Remotes {
+		args := []string{r.GitDir, "config", "--get", "remote." + rm.Name + ".url"}
+		out, _ := r.git(ctx, args)
+
+		switch out {
+		case rm.URL:
+		case "":
+			args = []string{r.GitDir, "remote", "add", rm.Name, rm.URL}
+			if _, em := r.git(ctx, args); em != "" {
+				r.Error(dsc, em)
+				return
+			}
+		default:
+			r.Error(dsc, fmt.Sprintf("fatal: %v URL != %v", rm.Name, rm.URL))
+			return
+		}
+	}
+}
+
+// GitLag records how many commits each remote's default branch
+// has that origin's doesn't. Remotes without the branch are
+// left at zero.
+func (r *Repo) GitLag(ctx context.Context) {
+
+	if !r.Verified || r.DefaultBranch == "" {
+		return
+	}
+
+	db := r.DefaultBranch
+
+	for i, rm := range r.Remotes {
+		span := "refs/remotes/origin/" + db + "..refs/remotes/" + rm.Name + "/" + db
+		args := []string{r.GitDir, "rev-list", "--count", span}
+		out, _ := r.git(ctx, args)
+		r.Remotes[i].Lag, _ = strconv.Atoi(out)
+	}
+}
+
+// Lagging returns the remotes that origin lags behind.
+func (r *Repo) Lagging() (rms []Remote) {
+	for _, rm := range r.Remotes {
+		if rm.Lag >= 1 {
+			rms = append(rms, rm)
+		}
+	}
+	return rms
+}
@@ -4,13 +4,16 @@ package repo
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jychri/brf"
 	"github.com/jychri/fchk"
@@ -23,22 +26,147 @@ import (
 
 // private
 
+// timeout is the deadline for Git commands that don't
+// set their own, e.g. everything other than fetch, clone,
+// pull and push.
+const timeout = time.Minute
+
+// run starts cmd in its own process group and waits for it
+// to exit. If ctx is done first, the whole group is killed
+// so that helpers spawned by Git (ssh, credential managers)
+// don't outlive it.
+func run(ctx context.Context, cmd *exec.Cmd) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// foreground commands share the terminal's process group
+	if cmd.Stdin == nil {
+		setpgid(cmd)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		kill(cmd)
+		<-done
+		return ctx.Err()
+	}
+}
+
+// expired returns an error message if ctx timed out or was canceled.
+func expired(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "fatal: timed out"
+	case context.Canceled:
+		return "fatal: canceled"
+	}
+	return ""
+}
+
+// deadline returns ctx with a timeout deadline, unless ctx
+// already has one.
+func deadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// env returns the environment for Git commands running in the
+// background. Terminal prompts are disabled and credential
+// requests go to GIT_ASKPASS and SSH_ASKPASS, which fail unless
+// the user has set them, so concurrent commands can't block on
+// or interleave username and password prompts.
+func env() []string {
+	e := append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "SSH_ASKPASS_REQUIRE=force")
+
+	if os.Getenv("GIT_ASKPASS") == "" {
+		e = append(e, "GIT_ASKPASS=false")
+	}
+
+	if os.Getenv("SSH_ASKPASS") == "" {
+		e = append(e, "SSH_ASKPASS=false")
+	}
+
+	return e
+}
+
+// auth returns true if em reports a failure to authenticate.
+func auth(em string) bool {
+	for _, s := range []string{
+		"terminal prompts disabled",
+		"could not read Username",
+		"could not read Password",
+		"Authentication failed",
+		"Invalid username or password",
+		"HTTP Basic: Access denied",
+		"Permission denied (publickey",
+	} {
+		if strings.Contains(em, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// command returns a Git command, configured for the background
+// or, if r.Foreground is true, for the terminal.
+func (r *Repo) command(args []string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+
+	if r.Foreground {
+		cmd.Stdin = os.Stdin
+	} else {
+		cmd.Env = env()
+	}
+
+	return cmd
+}
+
 // git runs a Git command and returns standard out
 // and standard error messages as strings out and em.
 // em is used rather than err to indicate that the
 // value is as string rather than an error.
-func (r *Repo) git(args []string) (out string, em string) {
+func (r *Repo) git(ctx context.Context, args []string) (out string, em string) {
+	return r.stream(ctx, args, nil)
+}
+
+// stream is git, but also copies standard error to w, if w
+// isn't nil, as the command runs.
+func (r *Repo) stream(ctx context.Context, args []string, w io.Writer) (out string, em string) {
 
 	if r.Verified == false {
 		return
 	}
 
+	ctx, cancel := deadline(ctx)
+	defer cancel()
+
 	var outb, errb bytes.Buffer
 
-	cmd := exec.Command("git", args...)
+	cmd := r.command(args)
 	cmd.Stderr = &errb
 	cmd.Stdout = &outb
-	cmd.Run()
+
+	if w != nil {
+		cmd.Stderr = io.MultiWriter(&errb, w)
+	}
+
+	run(ctx, cmd)
 
 	out = outb.String()
 	em = errb.String()
@@ -46,76 +174,332 @@ func (r *Repo) git(args []string) (out string, em string) {
 	out = strings.TrimSuffix(out, "\n")
 	em = strings.TrimSuffix(em, "\n")
 
+	if m := expired(ctx); m != "" {
+		em = m
+	}
+
+	if auth(em) {
+		r.AuthRequired = true
+	}
+
 	return out, em
 }
 
 // gitP runs a Git command and records the error
-// values in r.ErrorName and r.ErrorMessage.
-func (r *Repo) gitP(args []string, dsc string) {
+// values in r.ErrorName and r.ErrorMessage. gitP
+// returns false and marks r as Skipped if the
+// command fails.
+func (r *Repo) gitP(ctx context.Context, args []string, dsc string) bool {
 
 	if r.Verified == false {
-		return
+		return false
 	}
 
-	var errb bytes.Buffer
+	ctx, cancel := deadline(ctx)
+	defer cancel()
 
-	cmd := exec.Command("git", args...)
+	var outb, errb bytes.Buffer
+
+	cmd := r.command(args)
 	cmd.Stderr = &errb
-	cmd.Run()
+	cmd.Stdout = &outb
+	err := run(ctx, cmd)
 
 	em := errb.String()
 	em = strings.TrimSuffix(em, "\n")
 
+	// timeouts and cancellations end verification
+	if m := expired(ctx); m != "" {
+		r.Error(dsc, m)
+		r.Category = "Skipped"
+		return false
+	}
+
+	if auth(em) {
+		r.AuthRequired = true
+		r.AuthStep = dsc
+	}
+
+	if err != nil {
+
+		// some failures, like "nothing to commit", are only reported on standard out
+		if em == "" {
+			em = strings.TrimSuffix(outb.String(), "\n")
+		}
+
+		r.Error(dsc, em)
+		r.Verified = false
+		r.Category = "Skipped"
+		return false
+	}
+
 	if em != "" {
 		r.ErrorName = dsc
 		r.ErrorMessage = em
 	}
+
+	return true
+}
+
+// quiet runs a Git command regardless of r.Verified and without
+// recording errors. quiet is used to inspect and restore r after
+// a failed step, so it isn't canceled with the run.
+func (r *Repo) quiet(args []string) (string, bool) {
+	ctx, cancel := deadline(context.Background())
+	defer cancel()
+
+	var outb bytes.Buffer
+
+	cmd := r.command(args)
+	cmd.Stdout = &outb
+	err := run(ctx, cmd)
+
+	return strings.TrimSuffix(outb.String(), "\n"), err == nil
+}
+
+// head returns the SHA of HEAD.
+func (r *Repo) head() string {
+	out, _ := r.quiet([]string{"-C", r.RepoPath, "rev-parse", "-q", "--verify", "HEAD"})
+	return out
+}
+
+// conflicts returns the paths of unmerged files.
+func (r *Repo) conflicts() []string {
+	out, _ := r.quiet([]string{"-C", r.RepoPath, "diff", "--name-only", "--diff-filter=U"})
+	return strings.Fields(out)
+}
+
+// abort aborts a merge or rebase left by a failed pull
+// and resets HEAD to head.
+func (r *Repo) abort(head string) {
+	r.quiet([]string{"-C", r.RepoPath, "merge", "--abort"})
+	r.quiet([]string{"-C", r.RepoPath, "rebase", "--abort"})
+
+	if head != "" {
+		r.quiet([]string{"-C", r.RepoPath, "reset", "--hard", head})
+	}
+}
+
+// stashIndex returns the stash@{n} reflog entry for SHA sha.
+func (r *Repo) stashIndex(sha string) string {
+	out, _ := r.quiet([]string{"-C", r.RepoPath, "stash", "list", "--format=%H"})
+
+	for i, s := range strings.Fields(out) {
+		if s == sha {
+			return fmt.Sprintf("stash@{%v}", i)
+		}
+	}
+
+	return ""
+}
+
+// unstash restores r after the failed step dsc of a Stash-Pull-Pop:
+// HEAD is reset to head and the stash at r.StashRef is reapplied.
+// If it applies, the stash is dropped. If not, the working tree is
+// left clean at head and the stash is kept. Either way r is marked
+// Skipped with a message describing the state it was left in.
+func (r *Repo) unstash(f flags.Flags, head string, dsc string) {
+
+	r.abort(head)                                       // abort and reset to head
+	r.quiet([]string{"-C", r.RepoPath, "clean", "-fd"}) // remove files left by a partial pop
+
+	ref := r.stashIndex(r.StashRef)
+	aa := []string{"-C", r.RepoPath, "stash", "apply", "--index", r.StashRef}
+	_, applied := r.quiet(aa)
+
+	if applied && ref != "" {
+		r.quiet([]string{"-C", r.RepoPath, "stash", "drop", ref})
+	} else {
+		r.abort(head)
+		r.quiet([]string{"-C", r.RepoPath, "clean", "-fd"})
+	}
+
+	var b bytes.Buffer
+
+	switch dsc {
+	case "GitPull":
+		b.WriteString("fatal: pull failed")
+	case "GitPop":
+		b.WriteString("fatal: pop failed")
+	}
+
+	if len(r.Conflicts) >= 1 {
+		b.WriteString(fmt.Sprintf(" with conflicts in %v", brf.Summary(r.Conflicts, 12)))
+	}
+
+	sh := head
+	if len(sh) > 7 {
+		sh = sh[:7]
+	}
+
+	b.WriteString(fmt.Sprintf(", restored HEAD %v", sh))
+
+	if applied {
+		b.WriteString(" and local changes")
+		r.StashRef = ""
+	} else {
+		b.WriteString(fmt.Sprintf(", local changes kept in %v (%v)", ref, r.StashRef))
+	}
+
+	r.Error(dsc, b.String())
+	r.Verified = false
+	r.Category = "Skipped"
+
+	ew := emoji.Get("Warning") // Warning emoji
+	flags.Printv(f, "%v %v %v", ew, r.Name, strings.TrimPrefix(b.String(), "fatal: "))
+}
+
+// unpaired returns true if HEAD can't be compared to an upstream.
+func (r *Repo) unpaired() bool {
+	return r.Detached || r.Empty || r.NoUpstream
+}
+
+// expected returns the branch r should be on, r.Branch
+// if set in gisrc, r.DefaultBranch otherwise.
+func (r *Repo) expected() string {
+	if r.Branch != "" {
+		return r.Branch
+	}
+	return r.DefaultBranch
+}
+
+// cloneArgs returns the git clone options for r.Depth,
+// r.SingleBranch, r.Filter and r.Sparse.
+func (r *Repo) cloneArgs() (args []string) {
+	if r.Depth >= 1 {
+		args = append(args, "--depth", strconv.Itoa(r.Depth))
+	}
+
+	// --depth implies --single-branch unless told otherwise
+	switch {
+	case r.SingleBranch:
+		args = append(args, "--single-branch")
+	case r.Depth >= 1:
+		args = append(args, "--no-single-branch")
+	}
+
+	switch r.Filter {
+	case "blobless":
+		args = append(args, "--filter=blob:none")
+	case "treeless":
+		args = append(args, "--filter=tree:0")
+	}
+
+	if len(r.Sparse) >= 1 {
+		args = append(args, "--sparse")
+	}
+
+	return args
+}
+
+// shallow returns true if r has a shallow history.
+func (r *Repo) shallow() bool {
+	_, err := os.Stat(path.Join(r.GitPath, "shallow"))
+	return err == nil
+}
+
+// pull returns the git pull option for strategy s.
+// Unknown strategies fall back to "--ff-only".
+func pull(s string) string {
+	switch s {
+	case "rebase":
+		return "--rebase"
+	case "merge":
+		return "--no-rebase"
+	default:
+		return "--ff-only"
+	}
 }
 
 // Public
 
 // Repo models a Git repository.
 type Repo struct {
-	BundlePath       string   // "~/tmpgis"
-	Workspace        string   // "main" or "go-lang"
-	User             string   // "jychri"
-	Remote           string   // "github" or "gitlab"
-	Name             string   // "git-in-sync"
-	WorkspacePath    string   // "/Users/jychri/tmpgis/go-lang/"
-	RepoPath         string   // "/Users/jychri/tmpgis/go-lang/git-in-sync"
-	GitPath          string   // "/Users/jychri/tmpgis/go-lang/git-in-sync/.git"
-	GitDir           string   // "--git-dir=/Users/jychri/tmpgis/go-lang/git-in-sync/.git"
-	WorkTree         string   // "--work-tree=/Users/jychri/tmpgis/go-lang/git-in-sync"
-	URL              string   // "https://github.com/jychri/git-in-sync"
-	PendingClone     bool     // true if RepoPath or GitPath are empty
-	Verified         bool     // true if Repo continues to pass verification
-	ErrorMessage     string   // the last error message
-	ErrorName        string   // name of the last error
-	ErrorShort       string   // message in matched short form
-	Cloned           bool     // true if Repo was cloned
-	OriginURL        string   // "https://github.com/jychri/git-in-sync"
-	LocalBranch      string   // `git rev-parse --abbrev-ref HEAD`, "master"
-	LocalSHA         string   // `git rev-parse @`, "l00000ngSHA1slong324"
-	UpstreamSHA      string   // `git rev-parse @{u}`, "l00000ngSHA1slong324"
-	MergeSHA         string   // `git merge-base @ @{u}`, "l00000ngSHA1slong324"
-	UpstreamBranch   string   // `git rev-parse --abbrev-ref --symbolic-full-name @{u}`, "..."
-	DiffsNameOnly    []string // `git diff --name-only @{u}`, [a, b, c, d, e]
-	DiffsSummary     string   // "a, b, c..."
-	ShortStat        string   // `git diff --shortstat`, "x files changed, y insertions(+), z deletions(-)"
-	Changed          int      // count of changed files (x)
-	Insertions       int      // count of inserted files (y)
-	Deletions        int      // count of deleted files (z)
-	ShortStatSummary string   // "+y|-z" or "D" for Deleted if (x >= 1 && y == 0 && z == 0)
-	Clean            bool     // true if Changed, Insertions and Deletions are all 0
-	Untracked        bool     // true if if len(r.UntrackedFiles) >= 1
-	UntrackedFiles   []string // `git ls-files --others --exclude-standard`, [a, b, c, d, e]
-	UntrackedSummary string   // "a, b, c..."
-	Category         string   // Complete, Pending, Skipped, Scheduled
-	Status           string   // Complete is the last step
-	Action           string   // Push, Pull, Add-Commit-Push etc.
-	Prompt1          string   // First prompt message
-	Prompt2          string   // Second prompt message
-	Message          string   // Commit message
+	BundlePath       string            // "~/tmpgis"
+	Workspace        string            // "main" or "go-lang"
+	User             string            // "jychri"
+	Remote           string            // "github" or "gitlab"
+	Name             string            // "git-in-sync"
+	WorkspacePath    string            // "/Users/jychri/tmpgis/go-lang/"
+	RepoPath         string            // "/Users/jychri/tmpgis/go-lang/git-in-sync"
+	GitPath          string            // "/Users/jychri/tmpgis/go-lang/git-in-sync/.git"
+	GitDir           string            // "--git-dir=/Users/jychri/tmpgis/go-lang/git-in-sync/.git"
+	WorkTree         string            // "--work-tree=/Users/jychri/tmpgis/go-lang/git-in-sync"
+	URL              string            // "https://github.com/jychri/git-in-sync"
+	Pull             string            // pull strategy, "ff-only", "rebase" or "merge"
+	PendingClone     bool              // true if RepoPath or GitPath are empty
+	Verified         bool              // true if Repo continues to pass verification
+	ErrorMessage     string            // the last error message
+	ErrorName        string            // name of the last error
+	ErrorShort       string            // message in matched short form
+	Cloned           bool              // true if Repo was cloned
+	CloneAttempts    int               // number of clone attempts, 1 unless retried
+	StashRef         string            // SHA of the stash held by Stash-Pull-Pop
+	Conflicts        []string          // unmerged files after a failed pull or pop
+	Foreground       bool              // true if Git may prompt on the terminal
+	AuthRequired     bool              // true if Git failed to authenticate
+	AuthStep         string            // the step that failed to authenticate, "GitPush"
+	OriginURL        string            // "https://github.com/jychri/git-in-sync"
+	Mismatch         bool              // true if OriginURL doesn't match URL
+	Origin           string            // repair policy for a Mismatch, "ask", "remote" or "config"
+	LocalBranch      string            // `git symbolic-ref --short HEAD`, "master"
+	DefaultBranch    string            // `git symbolic-ref --short refs/remotes/origin/HEAD`, "master"
+	Branch           string            // expected branch from gisrc, "" for DefaultBranch
+	Remotes          []Remote          // remotes besides origin, "upstream"
+	Recurse          bool              // true to clone and update submodules
+	Submodules       []Submodule       // `git submodule status --recursive`
+	Depth            int               // clone depth, 0 for full history
+	SingleBranch     bool              // true to clone only the default branch
+	Filter           string            // partial clone filter, "blobless" or "treeless"
+	Sparse           []string          // sparse-checkout directories, nil for all
+	LFS              bool              // true if .gitattributes uses the lfs filter
+	LFSMissing       []string          // files whose LFS objects haven't been fetched
+	ScanPatterns     []string          // regexes for secrets, besides the defaults
+	MaxSize          int64             // size limit in bytes for files to commit
+	Changes          []string          // `git status --porcelain`, paths that `git add -A` stages
+	Staged           []string          // paths selected for staging, nil for all
+	Secrets          []string          // files to commit that look like secrets
+	Large            []string          // files to commit over MaxSize, "dump.sql 2048MB"
+	Detached         bool              // true if HEAD doesn't point to a branch
+	Dangling         bool              // true if a detached HEAD isn't on any branch
+	Empty            bool              // true if there are no commits
+	NoUpstream       bool              // true if LocalBranch has no upstream
+	LocalSHA         string            // `git rev-parse @`, "l00000ngSHA1slong324"
+	UpstreamSHA      string            // `git rev-parse @{u}`, "l00000ngSHA1slong324"
+	MergeSHA         string            // `git merge-base @ @{u}`, "l00000ngSHA1slong324"
+	UpstreamBranch   string            // `git rev-parse --abbrev-ref --symbolic-full-name @{u}`, "..."
+	DiffsNameOnly    []string          // `git diff --name-only @{u}`, [a, b, c, d, e]
+	DiffsSummary     string            // "a, b, c..."
+	ShortStat        string            // `git diff --shortstat`, "x files changed, y insertions(+), z deletions(-)"
+	Changed          int               // count of changed files (x)
+	Insertions       int               // count of inserted files (y)
+	Deletions        int               // count of deleted files (z)
+	ShortStatSummary string            // "+y|-z" or "D" for Deleted if (x >= 1 && y == 0 && z == 0)
+	Clean            bool              // true if Changed, Insertions and Deletions are all 0
+	Untracked        bool              // true if if len(r.UntrackedFiles) >= 1
+	UntrackedFiles   []string          // `git ls-files --others --exclude-standard`, [a, b, c, d, e]
+	UntrackedSummary string            // "a, b, c..."
+	Branches         []Branch          // local branches with an upstream
+	StaleBranches    []Stale           // local branches that are merged, gone or old
+	Stashes          []Stash           // stash entries, newest first
+	Category         string            // Complete, Pending, Skipped, Scheduled
+	Status           string            // Complete is the last step
+	Action           string            // Push, Pull, Add-Commit-Push etc.
+	Prompt1          string            // First prompt message
+	Prompt2          string            // Second prompt message
+	Message          string            // Commit message
+	Template         string            // commit message template, used without a Message
+	Conventional     bool              // true if commit messages must be conventional commits
+	AuthorName       string            // user.name for commits, "" for the repo's
+	AuthorEmail      string            // user.email for commits, "" for the repo's
+	SigningKey       string            // user.signingkey for commits, "" to not sign
+	SigningFormat    string            // gpg.format, "openpgp", "ssh" or "x509"
+	IdentityName     string            // `git config user.name`
+	IdentityEmail    string            // `git config user.email`
+	IdentityMismatch bool              // true if the repo's identity isn't AuthorName and AuthorEmail
+	Hooks            map[string]string // shell commands by event, "post-clone": "make bootstrap"
+
+	clone *progress // clone progress, read while cloning
 }
 
 // Init returns an initialized *Repo.
@@ -128,6 +512,10 @@ func Init(workspace string, user string, remote string, bundle string, name stri
 	r.User = user              // jychri
 	r.Remote = remote          // github, gitlab etc.
 	r.Name = name              // git-in-sync
+	r.Pull = "ff-only"         // ff-only, rebase or merge
+	r.Origin = "ask"           // ask, remote or config
+	r.clone = new(progress)    // clone progress
+	r.MaxSize = maxSize        // size limit for files to commit
 
 	// /Users/jychri/tmpgis/golang or /Users/jychri/tmpgis (main)
 	if workspace != "main" {
@@ -173,6 +561,27 @@ func (r *Repo) Error(dsc string, em string) {
 	}
 }
 
+// Canceled returns true if r's last Git command was canceled.
+func (r *Repo) Canceled() bool {
+	return r.ErrorMessage == "fatal: canceled"
+}
+
+// TimedOut returns true if r's last Git command timed out.
+func (r *Repo) TimedOut() bool {
+	return r.ErrorMessage == "fatal: timed out"
+}
+
+// Reset clears the last error and verifies r again, so
+// that a failed step can be re-run.
+func (r *Repo) Reset() {
+	r.ErrorName = ""
+	r.ErrorMessage = ""
+	r.ErrorShort = ""
+	r.AuthRequired = false
+	r.AuthStep = ""
+	r.Verified = true
+}
+
 // VerifyWorkspace verifies that the r.WorkspacePath is present and accessible.
 func (r *Repo) VerifyWorkspace(f flags.Flags, st *stat.Stat) {
 
@@ -227,26 +636,81 @@ func (r *Repo) GitSchedule(f flags.Flags, st *stat.Stat) {
 }
 
 // GitClone clones a Git repository from r.URL.
-func (r *Repo) GitClone(f flags.Flags) {
+func (r *Repo) GitClone(ctx context.Context, f flags.Flags) {
 	const dsc = "GitClone"
 
 	if !r.PendingClone {
 		return
 	}
 
-	// "cloning..."
-	flags.Printv(f, "%v cloning %v {%v}", emoji.Get("Box"), r.Name, r.Workspace)
+	defer r.clone.finish()
 
-	args := []string{"clone", r.URL, r.RepoPath}
-	if out, _ := r.git(args); out != "" {
-		r.Error(dsc, out)
-	} else {
+	args := []string{"clone", "--progress", r.URL, r.RepoPath}
+
+	if r.Recurse {
+		args = append(args, "--recurse-submodules")
+	}
+
+	args = append(args, r.cloneArgs()...)
+
+	var em string
+
+	// retry transient failures, waiting longer each time
+	for r.CloneAttempts = 1; ; r.CloneAttempts++ {
+		if em = r.cloneOnce(ctx, f, args); !transient(em) || r.CloneAttempts == attempts {
+			break
+		}
+
+		r.clone.set("waiting")
+		os.RemoveAll(r.RepoPath) // remove the partial clone
+
+		select {
+		case <-time.After(backoff << uint(r.CloneAttempts-1)):
+		case <-ctx.Done():
+		}
+	}
+
+	if r.CloneAttempts >= 2 && strings.Contains(em, "fatal") {
+		em = fmt.Sprintf("%v (%v attempts)", em, r.CloneAttempts)
+	}
+
+	switch {
+	case strings.HasPrefix(em, "fatal: timed out") || strings.HasPrefix(em, "fatal: canceled"):
+		os.RemoveAll(r.RepoPath) // remove the partial clone
+		r.Error(dsc, em)
+	case strings.Contains(em, "fatal"):
+		r.Error(dsc, em)
+	default:
 		r.Cloned = true
 	}
+
+	if !r.Cloned || len(r.Sparse) == 0 {
+		return
+	}
+
+	args = append([]string{"-C", r.RepoPath, "sparse-checkout", "set"}, r.Sparse...)
+	if _, em = r.git(ctx, args); em != "" {
+		r.Error(dsc, em)
+	}
+}
+
+// cloneOnce runs one clone attempt, recording its progress
+// in r.clone, and returns its error message.
+func (r *Repo) cloneOnce(ctx context.Context, f flags.Flags, args []string) string {
+	ctx, cancel := context.WithTimeout(ctx, f.Clone)
+	defer cancel()
+
+	r.clone.start(r.CloneAttempts)
+
+	if _, em := r.stream(ctx, args, r.clone); em == "fatal: timed out" || em == "fatal: canceled" {
+		return em
+	}
+
+	return r.clone.message()
 }
 
 // GitConfigOriginURL gets the remote origin URL for a Repo.
-func (r *Repo) GitConfigOriginURL() {
+func (r *Repo) GitConfigOriginURL(ctx context.Context) {
 	const dsc = "GitConfigOriginURL"
 
 	if !r.Verified {
@@ -254,7 +718,7 @@ func (r *Repo) GitConfigOriginURL() {
 	}
 
 	args := []string{r.GitDir, "config", "--get", "remote.origin.url"}
-	out, _ := r.git(args)
+	out, _ := r.git(ctx, args)
 	mod := strings.TrimSuffix(out, ".git")
 
 	switch {
@@ -263,22 +727,42 @@ func (r *Repo) GitConfigOriginURL() {
 	case mod == r.URL:
 		r.OriginURL = out
 	case out != r.URL:
+		r.OriginURL = out
+		r.Mismatch = true
 		r.Error(dsc, "fatal: URL != OriginURL")
 	default:
 		r.OriginURL = out
 	}
 }
 
+// GitSetOriginURL rewrites remote.origin.url to r.URL.
+func (r *Repo) GitSetOriginURL(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitSetOriginURL"                                    // description
+	ew := emoji.Get("Wrench")                                        // Wrench emoji
+	rn := r.Name                                                     // repo name
+	flags.Printv(f, "%v %v setting origin to %v", ew, rn, r.URL)     // print
+	args := []string{r.GitDir, "remote", "set-url", "origin", r.URL} // arguments
+	return r.gitP(ctx, args, dsc)                                    // command
+}
+
 // GitRemoteUpdate ...
-func (r *Repo) GitRemoteUpdate() {
+func (r *Repo) GitRemoteUpdate(ctx context.Context, f flags.Flags) {
 	const dsc = "GitRemoteUpdate"
 
 	if !r.Verified {
 		return
 	}
 
-	args := []string{r.GitDir, r.WorkTree, "fetch", "origin"}
-	_, em := r.git(args)
+	ctx, cancel := context.WithTimeout(ctx, f.Fetch)
+	defer cancel()
+
+	args := []string{r.GitDir, r.WorkTree, "fetch", "--multiple", "origin"}
+
+	for _, rm := range r.Remotes {
+		args = append(args, rm.Name)
+	}
+
+	_, em := r.git(ctx, args)
 
 	// Warnings for redirects to "*./git" are ignored.
 	wgit := strings.Join([]string{r.URL}, "/.git")
@@ -290,88 +774,162 @@ func (r *Repo) GitRemoteUpdate() {
 	}
 }
 
-// GitAbbrevRef ...
-func (r *Repo) GitAbbrevRef() {
+// GitAbbrevRef records the checked out branch in r.LocalBranch,
+// or sets r.Detached if HEAD doesn't point to a branch.
+func (r *Repo) GitAbbrevRef(ctx context.Context) {
 	const dsc = "GitAbbrevRef"
 
 	if !r.Verified {
 		return
 	}
 
-	args := []string{r.GitDir, r.WorkTree, "rev-parse", "--abbrev-ref", "HEAD"}
-	if out, em := r.git(args); em != "" {
+	args := []string{r.GitDir, r.WorkTree, "symbolic-ref", "-q", "--short", "HEAD"}
+	out, em := r.git(ctx, args)
+
+	switch {
+	case em != "":
 		r.Error(dsc, em)
-	} else {
+	case out == "":
+		r.Detached = true
+		r.LocalBranch = "HEAD"
+	default:
 		r.LocalBranch = out
 	}
+
+	if !r.Detached {
+		return
+	}
+
+	// a detached HEAD that isn't on any branch holds commits
+	// that a checkout would leave behind
+	args = []string{r.GitDir, "for-each-ref", "--count=1", "--contains", "HEAD", "refs/heads", "refs/remotes"}
+	if out, _ = r.git(ctx, args); out == "" {
+		r.Dangling = true
+	}
 }
 
-// GitLocalSHA ...
-func (r *Repo) GitLocalSHA() {
+// GitDefaultBranch records the remote's default branch in
+// r.DefaultBranch, "master". If origin/HEAD isn't set, origin
+// is asked for its HEAD, which is then recorded as origin/HEAD.
+// r.DefaultBranch is left empty if origin can't be reached.
+func (r *Repo) GitDefaultBranch(ctx context.Context) {
+
+	if !r.Verified {
+		return
+	}
+
+	args := []string{r.GitDir, "symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD"}
+	if out, _ := r.git(ctx, args); out != "" {
+		r.DefaultBranch = strings.TrimPrefix(out, "origin/")
+		return
+	}
+
+	// origin/HEAD is only set by clone, so ask origin and record it
+	args = []string{"-C", r.RepoPath, "remote", "set-head", "origin", "-a"}
+	if _, em := r.git(ctx, args); em != "" {
+		return
+	}
+
+	args = []string{r.GitDir, "symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD"}
+	if out, _ := r.git(ctx, args); out != "" {
+		r.DefaultBranch = strings.TrimPrefix(out, "origin/")
+	}
+}
+
+// GitLocalSHA records the SHA of HEAD in r.LocalSHA, or
+// sets r.Empty if there are no commits.
+func (r *Repo) GitLocalSHA(ctx context.Context) {
 	const dsc = "GitLocalSHA"
 
 	if !r.Verified {
 		return
 	}
 
-	args := []string{r.GitDir, r.WorkTree, "rev-parse", "@"}
-	if out, em := r.git(args); em != "" {
+	args := []string{r.GitDir, r.WorkTree, "rev-parse", "-q", "--verify", "@"}
+	out, em := r.git(ctx, args)
+
+	switch {
+	case em != "":
 		r.Error(dsc, em)
-	} else {
+	case out == "":
+		r.Empty = true
+	default:
 		r.LocalSHA = out
 	}
 }
 
-// GitUpstreamBranch ...
-func (r *Repo) GitUpstreamBranch() {
+// GitUpstreamBranch records the upstream of the checked out
+// branch in r.UpstreamBranch, or sets r.NoUpstream if there
+// isn't one.
+func (r *Repo) GitUpstreamBranch(ctx context.Context) {
 	const dsc = "GitUpstreamBranch"
 
-	if !r.Verified {
+	if !r.Verified || r.Detached || r.Empty {
 		return
 	}
 
 	args := []string{r.GitDir, r.WorkTree, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}"}
-	if out, em := r.git(args); em != "" {
+	out, em := r.git(ctx, args)
+
+	switch {
+	case strings.Contains(em, "no upstream configured"):
+		r.NoUpstream = true
+	case em != "":
 		r.Error(dsc, em)
-	} else {
+	default:
 		r.UpstreamBranch = out
 	}
 }
 
 // GitMergeBaseSHA ...
-func (r *Repo) GitMergeBaseSHA() {
+func (r *Repo) GitMergeBaseSHA(ctx context.Context, f flags.Flags) {
 	const dsc = "GitMergeBaseSHA"
 
-	if !r.Verified {
+	if !r.Verified || r.unpaired() {
 		return
 	}
 
 	args := []string{r.GitDir, r.WorkTree, "merge-base", "@", "@{u}"}
-	if out, em := r.git(args); em != "" {
+	out, em := r.git(ctx, args)
+
+	// a shallow history may end before the merge base, so
+	// deepen it a few times before giving up
+	for i := 0; out == "" && em == "" && i < 3 && r.shallow(); i++ {
+		fctx, cancel := context.WithTimeout(ctx, f.Fetch)
+		r.git(fctx, []string{r.GitDir, r.WorkTree, "fetch", "-q", "--deepen=50", "origin"})
+		cancel()
+		out, em = r.git(ctx, args)
+	}
+
+	switch {
+	case em != "":
 		r.Error(dsc, em)
-	} else {
+	case out == "" && r.shallow():
+		r.Error(dsc, "fatal: no merge base in shallow history")
+	default:
 		r.MergeSHA = out
 	}
 }
 
 // GitRevParseUpstream ...
-func (r *Repo) GitRevParseUpstream() {
+func (r *Repo) GitRevParseUpstream(ctx context.Context) {
 	const dsc = "GitRevParseUpstream"
 
-	if !r.Verified {
+	if !r.Verified || r.unpaired() {
 		return
 	}
 
 	args := []string{r.GitDir, r.WorkTree, "rev-parse", "@{u}"}
-	if out, em := r.git(args); em != "" {
+	if out, em := r.git(ctx, args); em != "" {
 		r.Error(dsc, em)
 	} else {
 		r.UpstreamSHA = out
 	}
 }
 
-// GitDiffsNameOnly ...
-func (r *Repo) GitDiffsNameOnly() {
+// GitDiffsNameOnly records files that differ from upstream, or from
+// HEAD if there's no upstream to compare to.
+func (r *Repo) GitDiffsNameOnly(ctx context.Context) {
 	var out, em string
 	const dsc = "GitDiffsNameOnly"
 
@@ -379,9 +937,20 @@ func (r *Repo) GitDiffsNameOnly() {
 		return
 	}
 
-	args := []string{r.GitDir, r.WorkTree, "diff", "--name-only", "@{u}"}
-	if out, em = r.git(args); em != "" {
-		r.Error(dsc, em)
+	rev := "@{u}"
+
+	switch {
+	case r.Empty:
+		rev = ""
+	case r.unpaired():
+		rev = "HEAD"
+	}
+
+	if rev != "" {
+		args := []string{r.GitDir, r.WorkTree, "diff", "--name-only", rev}
+		if out, em = r.git(ctx, args); em != "" {
+			r.Error(dsc, em)
+		}
 	}
 
 	if out == "" {
@@ -394,16 +963,16 @@ func (r *Repo) GitDiffsNameOnly() {
 }
 
 // GitShortstat ...
-func (r *Repo) GitShortstat() {
+func (r *Repo) GitShortstat(ctx context.Context) {
 	const dsc = "GitShortstat"
 
 	if !r.Verified {
 		return
 	}
 
-	// command
-	args := []string{r.GitDir, r.WorkTree, "diff", "--shortstat"}
-	if out, em := r.git(args); em != "" {
+	// command, changes inside submodules are left to GitSubmodules
+	args := []string{r.GitDir, r.WorkTree, "diff", "--shortstat", "--ignore-submodules=dirty"}
+	if out, em := r.git(ctx, args); em != "" {
 		r.Error(dsc, em)
 		// log.Printf("%v: Shortstat ERR: %v | %v", r.Name, out, em)
 	} else {
@@ -479,7 +1048,7 @@ func (r *Repo) GitShortstat() {
 }
 
 // GitUntracked ...
-func (r *Repo) GitUntracked() {
+func (r *Repo) GitUntracked(ctx context.Context) {
 
 	var out, em string
 	const dsc = "GitUntracked"
@@ -490,7 +1059,7 @@ func (r *Repo) GitUntracked() {
 
 	args := []string{r.GitDir, r.WorkTree, "ls-files", "--others", "--exclude-standard"}
 
-	out, em = r.git(args)
+	out, em = r.git(ctx, args)
 
 	if em != "" {
 		r.Error(dsc, em)
@@ -523,6 +1092,14 @@ func (r *Repo) SetStatus(f flags.Flags) {
 	}
 
 	switch {
+	case r.Empty:
+		r.Status = "Empty"
+	case r.Detached:
+		r.Status = "Detached"
+	case r.expected() != "" && r.LocalBranch != r.expected():
+		r.Status = "OffBranch"
+	case r.NoUpstream:
+		r.Status = "NoUpstream"
 	case r.LocalSHA == "":
 		r.Error(dsc, "fatal: r.LocalSHA = ''")
 	case r.UpstreamSHA == "":
@@ -538,6 +1115,30 @@ func (r *Repo) SetStatus(f flags.Flags) {
 	}
 
 	switch {
+	case r.Status == "Empty":
+		r.Category = "Pending"
+		r.Action = "Initial-Commit-Push"
+	case r.Status == "Detached" && r.Dangling:
+		r.Category = "Skipped"
+		r.Error(dsc, "fatal: detached HEAD isn't on any branch")
+	case r.Status == "Detached" && r.Clean == false:
+		r.Category = "Skipped"
+		r.Error(dsc, "fatal: detached HEAD has uncommitted changes")
+	case r.Status == "Detached" && r.expected() == "":
+		r.Category = "Skipped"
+		r.Error(dsc, "fatal: detached HEAD and origin's default branch is unknown")
+	case r.Status == "Detached":
+		r.Category = "Pending"
+		r.Action = "Checkout"
+	case r.Status == "OffBranch" && (r.Clean == false || r.Untracked == true):
+		r.Category = "Skipped"
+		r.Error(dsc, fmt.Sprintf("fatal: on %v, not %v, with uncommitted changes", r.LocalBranch, r.expected()))
+	case r.Status == "OffBranch":
+		r.Category = "Pending"
+		r.Action = "Checkout"
+	case r.Status == "NoUpstream":
+		r.Category = "Pending"
+		r.Action = "Upstream-Push"
 	case (r.Clean == true && r.Untracked == false && r.Status == "Ahead"):
 		r.Category = "Pending"
 		r.Status = "Ahead"
@@ -583,6 +1184,13 @@ func (r *Repo) SetStatus(f flags.Flags) {
 		r.Error(dsc, "No matching conditions")
 	}
 
+	// secrets and large files block commits until they're left
+	// out of the selection, which needs the prompt
+	if strings.Contains(r.Action, "Commit") && r.Blocked() && (f.Login() || f.Logout() || f.Mode == "oneline") {
+		r.Category = "Skipped"
+		r.Error(dsc, fmt.Sprintf("fatal: commit blocked, %v", r.Hits()))
+	}
+
 	if r.ErrorMessage != "" {
 		err := r.ErrorMessage
 		switch {
@@ -594,6 +1202,10 @@ func (r *Repo) SetStatus(f flags.Flags) {
 			r.ErrorShort = "fatal: URL mismatch"
 		case strings.Contains(err, "fatal: no matches found"):
 			r.ErrorShort = "fatal: no matches found"
+		case r.AuthRequired:
+			r.ErrorShort = "fatal: authentication required"
+		case strings.Contains(err, "fatal: commit blocked"):
+			r.ErrorShort = "fatal: commit blocked"
 		}
 	}
 
@@ -607,6 +1219,8 @@ func (r *Repo) SetStatus(f flags.Flags) {
 		// flags.Printv(f, "%v", r.Name)
 		r.Category = "Scheduled"
 		r.Action = "Push"
+	case f.Logout() && r.Category == "Pending" && r.Template != "" && r.Action == "Add-Commit-Push":
+		r.Category = "Scheduled"
 	}
 
 	var b bytes.Buffer
@@ -622,8 +1236,24 @@ func (r *Repo) SetStatus(f flags.Flags) {
 	sss := r.ShortStatSummary
 	ufc := len(r.UntrackedFiles)
 	us := r.UntrackedSummary
+	eh := emoji.Get("Hole")
+	ed := emoji.Get("Desert")
+	lb := r.LocalBranch
+	ls := r.LocalSHA
+
+	if len(ls) > 7 {
+		ls = ls[:7]
+	}
 
 	switch r.Status {
+	case "Empty":
+		s = fmt.Sprintf("%v %v is empty", eh, rn)
+	case "Detached":
+		s = fmt.Sprintf("%v %v is detached at %v", ed, rn, ls)
+	case "OffBranch":
+		s = fmt.Sprintf("%v %v is on %v, not %v", ed, rn, lb, r.expected())
+	case "NoUpstream":
+		s = fmt.Sprintf("%v %v %v has no upstream", ed, rn, lb)
 	case "Ahead":
 		s = fmt.Sprintf("%v %v is ahead of %v ", eb, rn, ub)
 	case "Behind":
@@ -654,14 +1284,27 @@ func (r *Repo) SetStatus(f flags.Flags) {
 		b.WriteString(s)
 	}
 
+	// secrets and large files block commits until they're
+	// left out of the selection
+	if strings.Contains(r.Action, "Commit") && r.Blocked() {
+		fmt.Fprintf(&b, "\n%v commit blocked, %v", emoji.Get("Stop"), r.Hits())
+	}
+
 	r.Prompt1 = b.String()
 
 	re := r.Remote
 	er := emoji.Get("Rocket")
 	eb = emoji.Get("Boat")
 	ec := emoji.Get("Clipboard")
+	db := r.expected()
 
 	switch r.Status {
+	case "Empty":
+		s = fmt.Sprintf("%v create an initial commit and push %v to %v? ", ec, lb, re)
+	case "Detached", "OffBranch":
+		s = fmt.Sprintf("%v check out %v? ", eb, db)
+	case "NoUpstream":
+		s = fmt.Sprintf("%v push %v to %v and set its upstream? ", er, lb, re)
 	case "Ahead":
 		s = fmt.Sprintf("%v push changes to %v? ", er, re)
 	case "Behind":
@@ -733,23 +1376,64 @@ func (r *Repo) UserConfirm(f flags.Flags) {
 		return
 	}
 
-	// prompt and read
-	em := emoji.Get("Memo")               // Memo emoji
-	fmt.Printf("%v commit message: ", em) // print
-	in, err = rdr.ReadString('\n')        // read
-
-	switch in {
-	case "n", "no", "nah", "0", "stop", "skip", "abort", "halt", "quit", "exit", "":
+	// select the files to stage, always if the commit is blocked
+	if (strings.Contains(r.Action, "Add") || r.Blocked()) && !r.choose(rdr) {
 		r.Category = "Skipped"
-		r.Message = ""
-	default:
+		return
+	}
+
+	em := emoji.Get("Memo") // Memo emoji
+
+	for {
+		in = "e" // open the editor
+
+		q := "%v commit message or [e]ditor: " // question
+
+		if r.Template != "" {
+			q = "%v commit message, [e]ditor or enter for the template: "
+		}
+
+		// prompt and read, unless the editor is the default
+		if !f.Editor {
+			fmt.Printf(q, em)                               // print
+			if in, err = rdr.ReadString('\n'); err != nil { // read
+				in = "n"
+			}
+			in = strings.TrimSpace(in) // trim trailing new line
+		}
+
+		switch {
+		case in == "e" || in == "edit" || in == "editor":
+			// open the editor, an empty message aborts the commit
+			if in, err = r.edit(); err != nil {
+				fmt.Printf("%v %v\n", em, err)
+			}
+		case in == "" && r.Template != "":
+			// fall back to the template on a bare enter
+			in = r.Expand(r.Template)
+			fmt.Printf("%v %v\n", em, in)
+		}
+
+		switch in {
+		case "n", "no", "nah", "0", "stop", "skip", "abort", "halt", "quit", "exit", "":
+			r.Category = "Skipped"
+			r.Message = ""
+			return
+		}
+
+		if r.Conventional && !conventional(in) {
+			fmt.Printf("%v not a conventional commit, \"type(scope): subject\"\n", em)
+			continue
+		}
+
 		r.Category = "Scheduled"
 		r.Message = in
+		return
 	}
 }
 
 // GitAdd ...
-func (r *Repo) GitAdd(f flags.Flags) {
+func (r *Repo) GitAdd(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitAdd"         // description
 	eo := emoji.Get("Outbox")    // Outbox emoji
 	rn := r.Name                 // repo name
@@ -759,6 +1443,13 @@ func (r *Repo) GitAdd(f flags.Flags) {
 	us := r.UntrackedSummary     // summary: untracked
 	sss := r.ShortStatSummary    // summary: (+/-)
 
+	// scan again, the tree may have changed since it was verified
+	if r.GitScan(ctx); r.Blocked() {
+		r.Error(dsc, fmt.Sprintf("fatal: commit blocked, %v", r.Hits()))
+		r.Category = "Skipped"
+		return false
+	}
+
 	switch r.Status {
 	case "Dirty", "DirtyUntracked", "DirtyAhead", "DirtyBehind":
 		flags.Printv(f, "%v %v adding changes [%v]{%v}(%v)", eo, rn, dfc, ds, sss)
@@ -767,11 +1458,18 @@ func (r *Repo) GitAdd(f flags.Flags) {
 	}
 
 	args := []string{"-C", r.RepoPath, "add", "-A"}
-	r.gitP(args, dsc) // arguments and command
+
+	// stage the selection, leaving the rest of the tree dirty
+	if r.Staged != nil {
+		flags.Printv(f, "%v %v staging [%v]{%v}", eo, rn, len(r.Staged), brf.Summary(r.Staged, 12))
+		args = append(append(args, "--"), r.Staged...)
+	}
+
+	return r.gitP(ctx, args, dsc) // arguments and command
 }
 
 // GitCommit ...
-func (r *Repo) GitCommit(f flags.Flags) {
+func (r *Repo) GitCommit(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitCommit"      // description
 	ef := emoji.Get("Fire")      // Fire emoji
 	rn := r.Name                 // repo name
@@ -788,59 +1486,181 @@ func (r *Repo) GitCommit(f flags.Flags) {
 		flags.Printv(f, "%v %v committing new files [%v]{%v}", ef, rn, ufc, us)
 	}
 
-	args := []string{"-C", r.RepoPath, "commit", "-m", r.Message}
-	r.gitP(args, dsc) // arguments and command
+	if r.Message == "" {
+		r.Message = r.Expand(r.Template)
+	}
+
+	if r.Conventional && !conventional(r.Message) {
+		r.Error(dsc, fmt.Sprintf("fatal: %q isn't a conventional commit", r.Message))
+		r.Category = "Skipped"
+		return false
+	}
+
+	args := append([]string{"-C", r.RepoPath}, r.identity()...)
+	args = append(args, "commit", "-m", r.Message)
+
+	// --author wins over GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL
+	if r.AuthorName != "" && r.AuthorEmail != "" {
+		args = append(args, "--author", fmt.Sprintf("%v <%v>", r.AuthorName, r.AuthorEmail))
+	}
+
+	// an initial commit may have nothing to add
+	if r.Status == "Empty" {
+		args = append(args, "--allow-empty")
+	}
+
+	return r.gitP(ctx, args, dsc) // arguments and command
 }
 
-// GitStash ...
-func (r *Repo) GitStash(f flags.Flags) {
+// GitStash stashes tracked and untracked changes, recording
+// the new stash in r.StashRef.
+func (r *Repo) GitStash(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitStash"                             // description
 	es := emoji.Get("Squirrel")                        // Squirrel emoji
 	rn := r.Name                                       // repo name
 	flags.Printv(f, "%v  %v stashing changes", es, rn) // print
-	args := []string{"-C", r.RepoPath, "stash"}        // arguments
-	r.gitP(args, dsc)                                  // command
+
+	sa := []string{"-C", r.RepoPath, "rev-parse", "-q", "--verify", "refs/stash"}
+	before, _ := r.quiet(sa)
+
+	args := []string{"-C", r.RepoPath, "stash", "push", "--include-untracked", "-m", "git-in-sync"}
+	if !r.gitP(ctx, args, dsc) {
+		return false
+	}
+
+	// "No local changes to save" doesn't create a stash
+	if after, _ := r.quiet(sa); after != before {
+		r.StashRef = after
+	}
+
+	return true
 }
 
 // GitPop ...
-func (r *Repo) GitPop(f flags.Flags) {
+func (r *Repo) GitPop(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitPop"                               // description
 	ep := emoji.Get("Popcorn")                         // Popcorn emoji
 	rn := r.Name                                       // repo name
 	flags.Printv(f, "%v  %v popping changes", ep, rn)  // print
 	args := []string{"-C", r.RepoPath, "stash", "pop"} // arguments
-	r.gitP(args, dsc)                                  // command
+
+	if !r.gitP(ctx, args, dsc) {
+		return false
+	}
+
+	r.StashRef = ""
+	return true
 }
 
-// GitPull ...
-func (r *Repo) GitPull(f flags.Flags) {
+// GitPull pulls changes from upstream. If the pull fails,
+// any merge or rebase in progress is aborted and HEAD is
+// reset to where it was.
+func (r *Repo) GitPull(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitPull"                                                 // description
 	es := emoji.Get("Ship")                                               // Ship emoji
 	rn := r.Name                                                          // repo name
 	ub := r.UpstreamBranch                                                // upstream branch
 	rr := r.Remote                                                        // remote
 	flags.Printv(f, "%v %v pulling changes from %v @ %v", es, rn, ub, rr) // print
-	args := []string{"-C", r.RepoPath, "pull"}                            // arguments
-	r.gitP(args, dsc)                                                     // command
+	head := r.head()                                                      // HEAD before pull
+	ctx, cancel := context.WithTimeout(ctx, f.Fetch)                      // fetch timeout
+	defer cancel()                                                        //
+	args := []string{"-C", r.RepoPath, "pull", pull(r.Pull)}              // arguments
+
+	if !r.gitP(ctx, args, dsc) {
+		r.Conflicts = r.conflicts()
+		r.abort(head)
+		return false
+	}
+
+	return true
 }
 
 // GitPush ...
-func (r *Repo) GitPush(f flags.Flags) {
+func (r *Repo) GitPush(ctx context.Context, f flags.Flags) bool {
 	const dsc = "GitPush"                                               // description
 	er := emoji.Get("Rocket")                                           // Rocket emoji
 	rn := r.Name                                                        // repo name
 	ub := r.UpstreamBranch                                              // upstream branch
 	rr := r.Remote                                                      // remote
 	flags.Printv(f, "%v %v pushing changes to %v @ %v", er, rn, ub, rr) // print
+	ctx, cancel := context.WithTimeout(ctx, f.Push)                     // push timeout
+	defer cancel()                                                      //
 	args := []string{"-C", r.RepoPath, "push"}                          // arguments
-	r.gitP(args, dsc)                                                   // command
+	return r.gitP(ctx, args, dsc)                                       // command
+}
+
+// GitPushUpstream pushes the checked out branch to origin and
+// sets it as the branch's upstream.
+func (r *Repo) GitPushUpstream(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitPushUpstream"                                               // description
+	er := emoji.Get("Rocket")                                                   // Rocket emoji
+	rn := r.Name                                                                // repo name
+	lb := r.LocalBranch                                                         // local branch
+	rr := r.Remote                                                              // remote
+	flags.Printv(f, "%v %v pushing %v to %v, setting upstream", er, rn, lb, rr) // print
+	ctx, cancel := context.WithTimeout(ctx, f.Push)                             // push timeout
+	defer cancel()                                                              //
+	args := []string{"-C", r.RepoPath, "push", "-u", "origin", lb}              // arguments
+	return r.gitP(ctx, args, dsc)                                               // command
+}
+
+// GitCheckout checks out the expected branch, r.Branch
+// or r.DefaultBranch.
+func (r *Repo) GitCheckout(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitCheckout"                                // description
+	eb := emoji.Get("Boat")                                  // Boat emoji
+	rn := r.Name                                             // repo name
+	db := r.expected()                                       // expected branch
+	flags.Printv(f, "%v %v checking out %v", eb, rn, db)     // print
+	args := []string{"-C", r.RepoPath, "checkout", "-q", db} // arguments
+	return r.gitP(ctx, args, dsc)                            // command
+}
+
+// GitStashPullPop stashes local changes, pulls and pops them
+// back, checking each step before the next. If the pull or
+// the pop fails, HEAD is reset to where it started and the
+// stash is reapplied there, leaving r as it was. The stash is
+// only dropped once it has been reapplied cleanly; otherwise
+// it's kept and reported in r.ErrorMessage.
+func (r *Repo) GitStashPullPop(ctx context.Context, f flags.Flags) bool {
+
+	head := r.head() // HEAD before stash
+
+	if !r.GitStash(ctx, f) {
+		return false
+	}
+
+	// nothing was stashed, nothing to pop
+	if r.StashRef == "" {
+		return r.GitPull(ctx, f)
+	}
+
+	if !r.GitPull(ctx, f) {
+		r.unstash(f, head, "GitPull")
+		return false
+	}
+
+	if !r.GitPop(ctx, f) {
+		r.Conflicts = r.conflicts()
+		r.unstash(f, head, "GitPop")
+		return false
+	}
+
+	return true
 }
 
 // GitClear ...
 func (r *Repo) GitClear() {
 	const dsc = "GitClean"
 	r.OriginURL = ""
+	r.Mismatch = false
 	r.LocalBranch = ""
+	r.DefaultBranch = ""
+	r.Detached = false
+	r.Dangling = false
+	r.Empty = false
+	r.NoUpstream = false
 	r.LocalSHA = ""
 	r.UpstreamBranch = ""
 	r.MergeSHA = ""
@@ -857,4 +1677,20 @@ func (r *Repo) GitClear() {
 	r.UntrackedSummary = ""
 	r.Untracked = false
 	r.Status = ""
+
+	for i := range r.Remotes {
+		r.Remotes[i].Lag = 0
+	}
+
+	r.Submodules = nil
+	r.LFS = false
+	r.LFSMissing = nil
+	r.Secrets = nil
+	r.Large = nil
+	r.Changes = nil
+	r.Staged = nil
+	r.IdentityName = ""
+	r.IdentityEmail = ""
+	r.IdentityMismatch = false
+	r.Stashes = nil
 }
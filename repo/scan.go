@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxSize is the default size limit for files to commit.
+const maxSize = 50 << 20
+
+// secrets match the contents of files that look like secrets.
+var secrets = []string{
+	`-----BEGIN (RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`,
+	`AKIA[0-9A-Z]{16}`,              // AWS access key
+	`gh[pousr]_[A-Za-z0-9]{36}`,     // GitHub token
+	`glpat-[A-Za-z0-9_-]{20}`,       // GitLab token
+	`xox[abposr]-[A-Za-z0-9-]{10,}`, // Slack token
+	`sk_live_[A-Za-z0-9]{24}`,       // Stripe key
+	`AIza[0-9A-Za-z_-]{35}`,         // Google API key
+}
+
+// rxn matches the names of files that hold secrets.
+var rxn = regexp.MustCompile(`(^|/)(\.env(\.[^/]+)?|id_(rsa|dsa|ecdsa|ed25519)|[^/]+\.(pem|p12|pfx|key))$`)
+
+// patterns returns the compiled secrets and r.ScanPatterns.
+func (r *Repo) patterns() (rxs []*regexp.Regexp) {
+	for _, s := range append(secrets, r.ScanPatterns...) {
+		if rx, err := regexp.Compile(s); err == nil {
+			rxs = append(rxs, rx)
+		}
+	}
+	return rxs
+}
+
+// head returns up to n bytes from the start of the file at p.
+func head(p string, n int64) ([]byte, error) {
+	fl, err := os.Open(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fl.Close()
+	return ioutil.ReadAll(io.LimitReader(fl, n))
+}
+
+// GitScan records the changes in r.Changes that are going to be
+// staged, all of them or the selection in r.Staged, that look
+// like secrets in r.Secrets, or are larger than r.MaxSize in
+// r.Large. Only the first r.MaxSize bytes of a file are read.
+func (r *Repo) GitScan(ctx context.Context) {
+
+	if !r.Verified {
+		return
+	}
+
+	r.Secrets = nil
+	r.Large = nil
+	r.GitChanges(ctx)
+	rxs := r.patterns()
+
+	for _, p := range r.Changes {
+		if !r.staged(p) {
+			continue
+		}
+
+		fi, err := os.Lstat(path.Join(r.RepoPath, p))
+
+		// deleted files, directories and links aren't read
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+
+		if fi.Size() > r.MaxSize {
+			r.Large = append(r.Large, fmt.Sprintf("%v %vMB", p, fi.Size()>>20))
+			continue
+		}
+
+		if rxn.MatchString(p) {
+			r.Secrets = append(r.Secrets, p)
+			continue
+		}
+
+		bs, err := head(path.Join(r.RepoPath, p), r.MaxSize)
+
+		if err != nil {
+			continue
+		}
+
+		for _, rx := range rxs {
+			if rx.Match(bs) {
+				r.Secrets = append(r.Secrets, p)
+				break
+			}
+		}
+	}
+}
+
+// Blocked returns true if GitScan found secrets or large files.
+func (r *Repo) Blocked() bool {
+	return len(r.Secrets)+len(r.Large) >= 1
+}
+
+// Hits returns the files found by GitScan, "secrets (.env),
+// large files (dump.sql 2048MB)".
+func (r *Repo) Hits() string {
+	var ss []string
+
+	if len(r.Secrets) >= 1 {
+		ss = append(ss, fmt.Sprintf("secrets (%v)", strings.Join(r.Secrets, ", ")))
+	}
+
+	if len(r.Large) >= 1 {
+		ss = append(ss, fmt.Sprintf("large files (%v)", strings.Join(r.Large, ", ")))
+	}
+
+	return strings.Join(ss, ", ")
+}
@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+)
+
+// pick returns the paths in ps selected by in, a list of
+// numbers, "1 3", ranges, "2-4", and patterns to exclude,
+// "!*.log". Numbers count from 1. With only patterns, every
+// path that doesn't match is selected. An empty in, "a" or
+// "all" selects every path and returns nil.
+func pick(in string, ps []string) ([]string, error) {
+	var ex []string
+
+	in = strings.TrimSpace(in)
+
+	if in == "" || in == "a" || in == "all" {
+		return nil, nil
+	}
+
+	sel := make(map[int]bool)
+
+	for _, tk := range strings.Fields(strings.Replace(in, ",", " ", -1)) {
+
+		if strings.HasPrefix(tk, "!") {
+			if _, err := path.Match(tk[1:], ""); err != nil {
+				return nil, fmt.Errorf("bad pattern %v", tk)
+			}
+			ex = append(ex, tk[1:])
+			continue
+		}
+
+		lo, hi := tk, tk
+
+		if i := strings.Index(tk, "-"); i >= 1 {
+			lo, hi = tk[:i], tk[i+1:]
+		}
+
+		l, err1 := strconv.Atoi(lo)
+		h, err2 := strconv.Atoi(hi)
+
+		if err1 != nil || err2 != nil || l < 1 || h > len(ps) || l > h {
+			return nil, fmt.Errorf("bad selection %v", tk)
+		}
+
+		for i := l; i <= h; i++ {
+			sel[i-1] = true
+		}
+	}
+
+	out := make([]string, 0)
+
+	for i, p := range ps {
+		if len(sel) >= 1 && !sel[i] {
+			continue
+		}
+
+		if excluded(p, ex) {
+			continue
+		}
+
+		out = append(out, p)
+	}
+
+	return out, nil
+}
+
+// excluded returns true if p, or its base name, matches
+// one of the patterns in ex, or is in a directory in ex.
+func excluded(p string, ex []string) bool {
+	for _, x := range ex {
+		if m, _ := path.Match(x, p); m {
+			return true
+		}
+
+		if m, _ := path.Match(x, path.Base(p)); m {
+			return true
+		}
+
+		if strings.HasSuffix(x, "/") && strings.HasPrefix(p, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// staged returns true if p is staged by the selection in
+// r.Staged, or if there's no selection.
+func (r *Repo) staged(p string) bool {
+	if r.Staged == nil {
+		return true
+	}
+
+	for _, s := range r.Staged {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// GitChanges records the paths `git add -A` would stage in
+// r.Changes, both changed and untracked, with renames as
+// their new path.
+func (r *Repo) GitChanges(ctx context.Context) {
+	const dsc = "GitChanges"
+
+	if !r.Verified {
+		return
+	}
+
+	r.Changes = nil
+
+	args := []string{"-C", r.RepoPath, "status", "--porcelain", "-z", "--untracked-files=all", "--ignore-submodules=dirty"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	// "XY path\0", followed by "orig\0" for renames and copies
+	fs := strings.Split(out, "\x00")
+
+	for i := 0; i < len(fs); i++ {
+		if len(fs[i]) < 4 {
+			continue
+		}
+
+		r.Changes = append(r.Changes, fs[i][3:])
+
+		if fs[i][0] == 'R' || fs[i][0] == 'C' {
+			i++
+		}
+	}
+}
+
+// choose lists r.Changes with numbers and reads a selection
+// from rdr into r.Staged, asking again until it's valid or
+// leaves out every blocked file. A single change is selected
+// without asking, unless it's blocked. choose returns false
+// if nothing was selected.
+func (r *Repo) choose(rdr *bufio.Reader) bool {
+	ec := emoji.Get("Clipboard") // Clipboard emoji
+
+	if len(r.Changes) <= 1 && !r.Blocked() {
+		return true
+	}
+
+	for i, p := range r.Changes {
+		fmt.Printf("  %2d %v\n", i+1, p)
+	}
+
+	for {
+		fmt.Printf("%v stage [a]ll, [n]one, numbers \"1 3-4\" or exclusions \"!*.log\": ", ec)
+
+		in, err := rdr.ReadString('\n')
+
+		if err != nil {
+			return false
+		}
+
+		switch strings.TrimSpace(in) {
+		case "n", "no", "none", "skip", "quit":
+			return false
+		}
+
+		ps, err := pick(in, r.Changes)
+
+		switch {
+		case err != nil:
+			fmt.Println(err)
+			continue
+		case ps != nil && len(ps) == 0:
+			return false
+		}
+
+		r.Staged = ps
+
+		if r.GitScan(context.Background()); r.Blocked() {
+			fmt.Printf("%v commit blocked, %v\n", emoji.Get("Stop"), r.Hits())
+			continue
+		}
+
+		return true
+	}
+}
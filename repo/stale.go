@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// Stale models a local branch that may be ready to delete.
+type Stale struct {
+	Name   string // "feature"
+	Merged bool   // true if merged into the expected branch
+	Gone   bool   // true if its upstream no longer exists
+	Age    int    // days since its last commit
+	Old    bool   // true if Age is at least the limit
+}
+
+// String returns why s is stale, "feature (merged, gone, 120 days)".
+func (s Stale) String() string {
+	var ss []string
+
+	if s.Merged {
+		ss = append(ss, "merged")
+	}
+
+	if s.Gone {
+		ss = append(ss, "gone")
+	}
+
+	if s.Old {
+		ss = append(ss, fmt.Sprintf("%v days", s.Age))
+	}
+
+	return fmt.Sprintf("%v (%v)", s.Name, strings.Join(ss, ", "))
+}
+
+// AgeOnly returns true if s is stale only by age, so its
+// commits may not be merged anywhere.
+func (s Stale) AgeOnly() bool {
+	return s.Old && !s.Merged && !s.Gone
+}
+
+// GitStale records the local branches in r that are merged into
+// the expected branch, whose upstream is gone, or that have had
+// no commits for days or more, in r.StaleBranches. The checked
+// out and expected branches are never stale. If days is 0,
+// branches aren't stale by age.
+func (r *Repo) GitStale(ctx context.Context, days int) {
+	const dsc = "GitStale"
+
+	r.StaleBranches = nil
+	eb := r.expected()
+
+	if !r.Verified || eb == "" {
+		return
+	}
+
+	fs := []string{
+		"%(refname:short)",
+		"%(upstream:track,nobracket)",
+		"%(committerdate:unix)",
+		"%(HEAD)",
+	}
+
+	format := "--format=" + strings.Join(fs, "%09")
+	args := []string{"-C", r.RepoPath, "for-each-ref", format, "refs/heads"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	args = []string{"-C", r.RepoPath, "for-each-ref", "--format=%(refname:short)", "--merged", "refs/heads/" + eb, "refs/heads"}
+	mout, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	merged := make(map[string]bool)
+
+	for _, n := range strings.Split(mout, "\n") {
+		merged[n] = true
+	}
+
+	for _, l := range strings.Split(out, "\n") {
+		fs := strings.Split(l, "\t")
+
+		if len(fs) != 4 || fs[0] == eb || fs[3] == "*" {
+			continue
+		}
+
+		s := Stale{Name: fs[0], Merged: merged[fs[0]], Gone: fs[1] == "gone"}
+
+		if ts, err := strconv.ParseInt(fs[2], 10, 64); err == nil {
+			s.Age = int(time.Since(time.Unix(ts, 0)).Hours() / 24)
+		}
+
+		s.Old = days >= 1 && s.Age >= days
+
+		if s.Merged || s.Gone || s.Old {
+			r.StaleBranches = append(r.StaleBranches, s)
+		}
+	}
+}
+
+// GitDeleteStale deletes the stale branches ss from r, one at
+// a time. Merged branches are deleted with "branch -d", so Git
+// refuses if they aren't merged into HEAD or their upstream.
+// Other branches are force deleted, as branches that are gone
+// or old may not be merged. A refusal doesn't stop the rest.
+func (r *Repo) GitDeleteStale(ctx context.Context, f flags.Flags, ss []Stale) bool {
+	const dsc = "GitDeleteStale"
+
+	if len(ss) == 0 {
+		return true
+	}
+
+	es := emoji.Get("Slash")                                         // Slash emoji
+	rn := r.Name                                                     // repo name
+	flags.Printv(f, "%v %v deleting [%v] branches", es, rn, len(ss)) // print
+
+	var failed []string
+
+	for _, s := range ss {
+		d := "-D" // force
+
+		if s.Merged {
+			d = "-d"
+		}
+
+		if !r.gitP(ctx, []string{"-C", r.RepoPath, "branch", d, s.Name}, dsc) {
+			failed = append(failed, fmt.Sprintf("%v (%v)", s.Name, r.ErrorMessage))
+			r.Reset()
+		}
+	}
+
+	if len(failed) >= 1 {
+		r.Error(dsc, "fatal: not deleted, "+strings.Join(failed, ", "))
+		r.Category = "Skipped"
+		return false
+	}
+
+	return true
+}
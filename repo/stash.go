@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// Stash models a stash entry.
+type Stash struct {
+	Index   int    // 0 for "stash@{0}"
+	Age     int    // days since it was stashed
+	Message string // "WIP on master: 1a2b3c4 Add flags"
+}
+
+// String returns s as "stash@{0} 12 days, WIP on master: ...".
+func (s Stash) String() string {
+	return fmt.Sprintf("%v %v days, %v", s.ref(), s.Age, s.Message)
+}
+
+// ref returns the name of s, "stash@{0}".
+func (s Stash) ref() string {
+	return fmt.Sprintf("stash@{%v}", s.Index)
+}
+
+// GitStashes records the stash entries in r in r.Stashes.
+func (r *Repo) GitStashes(ctx context.Context) {
+	const dsc = "GitStashes"
+
+	r.Stashes = nil
+
+	if !r.Verified {
+		return
+	}
+
+	args := []string{"-C", r.RepoPath, "stash", "list", "--format=%gd%x09%ct%x09%gs"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	if out == "" {
+		return
+	}
+
+	for _, l := range strings.Split(out, "\n") {
+		fs := strings.SplitN(l, "\t", 3)
+
+		if len(fs) != 3 {
+			continue
+		}
+
+		var s Stash
+
+		s.Message = fs[2]
+		s.Index, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(fs[0], "stash@{"), "}"))
+
+		if ts, err := strconv.ParseInt(fs[1], 10, 64); err == nil {
+			s.Age = int(time.Since(time.Unix(ts, 0)).Hours() / 24)
+		}
+
+		r.Stashes = append(r.Stashes, s)
+	}
+}
+
+// GitStashShow returns the diff of stash entry i.
+func (r *Repo) GitStashShow(ctx context.Context, i int) (string, bool) {
+	const dsc = "GitStashShow"
+
+	args := []string{"-C", r.RepoPath, "stash", "show", "--stat", "-p", Stash{Index: i}.ref()}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return "", false
+	}
+
+	return out, r.Verified
+}
+
+// GitStashApply pops or drops, as action, the stash entries
+// numbered is. Entries are handled from the highest number
+// down, so that earlier entries keep their numbers.
+func (r *Repo) GitStashApply(ctx context.Context, f flags.Flags, action string, is []int) bool {
+	const dsc = "GitStashApply"
+
+	is = append([]int(nil), is...)
+	sort.Sort(sort.Reverse(sort.IntSlice(is)))
+
+	for _, i := range is {
+		ref := Stash{Index: i}.ref()                                   // stash@{i}
+		ef := emoji.Get("FileCabinet")                                 // FileCabinet emoji
+		flags.Printv(f, "%v %v %v %v", ef, r.Name, action, ref)        // print
+		args := []string{"-C", r.RepoPath, "stash", action, "-q", ref} // arguments
+
+		if !r.gitP(ctx, args, dsc) {
+			return false
+		}
+	}
+
+	return true
+}
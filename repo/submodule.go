@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+)
+
+// Submodule models a submodule of a Repo.
+type Submodule struct {
+	Path          string // "vendor/brf"
+	SHA           string // commit recorded in the index
+	Uninitialized bool   // true if the submodule isn't checked out
+	OutOfDate     bool   // true if the checkout isn't the recorded commit
+	Conflicted    bool   // true if the recorded commit has conflicts
+	Dirty         bool   // true if the submodule has changes of its own
+}
+
+// String returns the state of s, "vendor/brf out of date, dirty".
+func (s Submodule) String() string {
+	var ss []string
+
+	if s.Uninitialized {
+		ss = append(ss, "uninitialized")
+	}
+
+	if s.OutOfDate {
+		ss = append(ss, "out of date")
+	}
+
+	if s.Conflicted {
+		ss = append(ss, "conflicted")
+	}
+
+	if s.Dirty {
+		ss = append(ss, "dirty")
+	}
+
+	if len(ss) == 0 {
+		return s.Path
+	}
+
+	return strings.Join([]string{s.Path, strings.Join(ss, ", ")}, " ")
+}
+
+// GitSubmodules records the submodules of r and their state
+// in r.Submodules.
+func (r *Repo) GitSubmodules(ctx context.Context) {
+	const dsc = "GitSubmodules"
+
+	if !r.Verified {
+		return
+	}
+
+	if _, err := os.Stat(path.Join(r.RepoPath, ".gitmodules")); err != nil {
+		return
+	}
+
+	// " sha path (describe)", prefixed '-', '+' or 'U' if not current
+	args := []string{"-C", r.RepoPath, "submodule", "status", "--recursive"}
+	out, em := r.git(ctx, args)
+
+	if em != "" {
+		r.Error(dsc, em)
+		return
+	}
+
+	idx := make(map[string]int)
+
+	for _, l := range strings.Split(out, "\n") {
+		if len(l) < 2 {
+			continue
+		}
+
+		fs := strings.Fields(l[1:])
+
+		if len(fs) < 2 {
+			continue
+		}
+
+		s := Submodule{Path: fs[1], SHA: fs[0]}
+
+		switch l[0] {
+		case '-':
+			s.Uninitialized = true
+		case '+':
+			s.OutOfDate = true
+		case 'U':
+			s.Conflicted = true
+		}
+
+		idx[s.Path] = len(r.Submodules)
+		r.Submodules = append(r.Submodules, s)
+	}
+
+	// "1 .M S.M. ... path", where S<c><m><u> marks a submodule
+	// with a new commit, tracked changes or untracked files
+	args = []string{"-C", r.RepoPath, "status", "--porcelain=v2", "--ignore-submodules=none"}
+	out, _ = r.git(ctx, args)
+
+	for _, l := range strings.Split(out, "\n") {
+		fs := strings.Fields(l)
+
+		if len(fs) < 9 || fs[0] != "1" || !strings.HasPrefix(fs[2], "S") || len(fs[2]) != 4 {
+			continue
+		}
+
+		if i, ok := idx[fs[8]]; ok && (fs[2][2] == 'M' || fs[2][3] == 'U') {
+			r.Submodules[i].Dirty = true
+		}
+	}
+}
+
+// Unsynced returns the submodules that are uninitialized,
+// out of date, conflicted or dirty.
+func (r *Repo) Unsynced() (ss []Submodule) {
+	for _, s := range r.Submodules {
+		if s.String() != s.Path {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// GitSubmoduleUpdate checks out the recorded commit of every
+// submodule, initializing them as needed. GitSubmoduleUpdate
+// returns true without running if r.Recurse is false or r has
+// no submodules.
+func (r *Repo) GitSubmoduleUpdate(ctx context.Context, f flags.Flags) bool {
+	const dsc = "GitSubmoduleUpdate"
+
+	if !r.Recurse {
+		return true
+	}
+
+	if _, err := os.Stat(path.Join(r.RepoPath, ".gitmodules")); err != nil {
+		return true
+	}
+
+	eb := emoji.Get("Box")                                                             // Box emoji
+	rn := r.Name                                                                       // repo name
+	flags.Printv(f, "%v %v updating submodules", eb, rn)                               // print
+	ctx, cancel := context.WithTimeout(ctx, f.Fetch)                                   // fetch timeout
+	defer cancel()                                                                     //
+	args := []string{"-C", r.RepoPath, "submodule", "update", "--init", "--recursive"} // arguments
+	return r.gitP(ctx, args, dsc)                                                      // command
+}
@@ -0,0 +1,185 @@
+package repos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jychri/brf"
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+)
+
+// private
+
+// prefixer writes each complete line to w as "name | line",
+// holding mu so that lines from concurrent repos don't interleave.
+type prefixer struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixer) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		p.line(p.buf[:i])
+		p.buf = p.buf[i+1:]
+	}
+
+	return len(b), nil
+}
+
+func (p *prefixer) line(b []byte) {
+	p.mu.Lock()
+	fmt.Fprintf(p.w, "%v | %s\n", p.prefix, b)
+	p.mu.Unlock()
+}
+
+// flush writes a trailing line without a newline.
+func (p *prefixer) flush() {
+	if len(p.buf) >= 1 {
+		p.line(p.buf)
+		p.buf = nil
+	}
+}
+
+// rxq matches arguments that don't need shell quoting.
+var rxq = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// command returns the shell command for as. A single argument
+// is run as is, "make && make test". Several are quoted so that
+// each reaches the command intact, grep "foo bar".
+func command(as []string) string {
+	if len(as) == 1 {
+		return as[0]
+	}
+
+	qs := make([]string, len(as))
+
+	for i, a := range as {
+		if rxq.MatchString(a) {
+			qs[i] = a
+		} else {
+			qs[i] = "'" + strings.Replace(a, "'", `'\''`, -1) + "'"
+		}
+	}
+
+	return strings.Join(qs, " ")
+}
+
+// width returns the length of the longest name in rs.
+func (rs Repos) width() (n int) {
+	for _, r := range rs {
+		if len(r.Name) > n {
+			n = len(r.Name)
+		}
+	}
+	return n
+}
+
+// execResult prints the exit code of sh in r, followed by its
+// output if it was grouped.
+func execResult(f flags.Flags, r *repo.Repo, code int, err error, d time.Duration, out string) {
+	d = d.Round(time.Millisecond)
+
+	switch {
+	case err != nil:
+		ew := emoji.Get("Warning")                     // Warning emoji
+		flags.Printv(f, "%v %v (%v)", ew, r.Name, err) // print error
+	case code == 0:
+		ec := emoji.Get("Checkmark")                 // Checkmark emoji
+		flags.Printv(f, "%v %v {%v}", ec, r.Name, d) // print success
+	default:
+		es := emoji.Get("Stop")                                    // Stop emoji
+		flags.Printv(f, "%v %v exit %v {%v}", es, r.Name, code, d) // print failure
+	}
+
+	if out = strings.TrimRight(out, "\n"); out != "" {
+		fmt.Println(out)
+	}
+}
+
+// Public
+
+// Exec runs the command in f.Args in each Repo in rs, at most
+// f.Jobs at a time, and returns the number of Repos where it
+// failed or couldn't run. Output is printed per Repo when each
+// finishes if f.Output is "grouped", or line by line with the
+// Repo's name as a prefix if f.Output is "prefixed".
+func (rs Repos) Exec(ctx context.Context, f flags.Flags, ti *timer.Timer) int {
+
+	sh := command(f.Args)
+
+	if sh == "" {
+		log.Fatalf("exec: no command, try 'gis exec git status -s'")
+	}
+
+	er := emoji.Get("Run")                                      // Run emoji
+	flags.Printv(f, "%v running '%v' in [%v]", er, sh, len(rs)) // print start
+
+	var mu sync.Mutex
+	var failed []string
+	n := rs.width()
+
+	rs.each(f, func(r *repo.Repo) {
+		var code int
+		var err error
+		var b bytes.Buffer
+
+		start := time.Now()
+
+		if f.Output == "prefixed" {
+			p := &prefixer{mu: &mu, w: os.Stdout, prefix: fmt.Sprintf("%-*v", n, r.Name)}
+			code, err = r.Exec(ctx, sh, p)
+			p.flush()
+		} else {
+			code, err = r.Exec(ctx, sh, &b)
+		}
+
+		d := time.Since(start)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		execResult(f, r, code, err, d, b.String())
+
+		if code != 0 {
+			failed = append(failed, r.Name)
+		}
+	})
+
+	ti.Mark("exec") // mark exec
+
+	ts := ti.Split()   // last split
+	tt := ti.Elapsed() // elapsed time
+
+	if len(failed) == 0 {
+		et := emoji.Get("ThumbsUp")                                      // ThumbsUp emoji
+		flags.Printv(f, "%v ran in [%v] {%v / %v}", et, len(rs), ts, tt) // print summary
+		return 0
+	}
+
+	fs := brf.Summary(failed, 25)                                                               // short summary
+	es := emoji.Get("Stop")                                                                     // Stop emoji
+	flags.Printv(f, "%v failed in [%v/%v](%v) {%v / %v}", es, len(failed), len(rs), fs, ts, tt) // print summary
+	return len(failed)
+}
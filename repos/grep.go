@@ -0,0 +1,88 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+)
+
+// Public
+
+// Search searches tracked files in each Repo in rs, at most
+// f.Jobs at a time, and prints the results in Repo order with
+// each path prefixed by the Repo's name. "grep" passes f.Args
+// to "git grep", "files" matches f.Args as pathspecs. Like
+// grep, Search returns 0 if anything matched, 1 if nothing did
+// and 2 if the search failed in any Repo.
+func (rs Repos) Search(ctx context.Context, f flags.Flags, ti *timer.Timer) int {
+
+	if len(f.Args) == 0 {
+		log.Fatalf("%v: no pattern, try 'gis %v -n TODO'", f.Command, f.Command)
+	}
+
+	if err := repo.GrepArgs(f.Args); f.Command == "grep" && err != nil {
+		log.Fatalf("grep: %v", err)
+	}
+
+	et := emoji.Get("Telescope")                                      // Telescope emoji
+	as := strings.Join(f.Args, " ")                                   // short args
+	flags.Printv(f, "%v %v '%v' in [%v]", et, f.Command, as, len(rs)) // print start
+
+	lss := make([][]string, len(rs))
+	errs := make([]error, len(rs))
+	ix := make(map[*repo.Repo]int)
+
+	for i, r := range rs {
+		ix[r] = i
+	}
+
+	rs.each(f, func(r *repo.Repo) {
+		i := ix[r]
+
+		if f.Command == "files" {
+			lss[i], errs[i] = r.GitLsFiles(ctx, f.Args)
+		} else {
+			lss[i], errs[i] = r.GitGrep(ctx, f.Args)
+		}
+	})
+
+	ti.Mark("search") // mark search
+
+	var n, m, failed int
+
+	for i, r := range rs {
+		if errs[i] != nil {
+			ew := emoji.Get("Warning")                         // Warning emoji
+			flags.Printv(f, "%v %v (%v)", ew, r.Name, errs[i]) // print error
+			failed++
+			continue
+		}
+
+		if len(lss[i]) >= 1 {
+			fmt.Println(strings.Join(lss[i], "\n"))
+			n += len(lss[i])
+			m++
+		}
+	}
+
+	ts := ti.Split()                                                                 // last split
+	tt := ti.Elapsed()                                                               // elapsed time
+	ef := emoji.Get("Finger")                                                        // Finger emoji
+	flags.Printv(f, "%v [%v] lines in [%v/%v] {%v / %v}", ef, n, m, len(rs), ts, tt) // print summary
+
+	switch {
+	case failed >= 1:
+		return 2
+	case n == 0:
+		return 1
+	}
+
+	return 0
+}
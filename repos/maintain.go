@@ -0,0 +1,102 @@
+package repos
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jychri/brf"
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+	"github.com/jychri/git-in-sync/stat"
+)
+
+// Public
+
+// Maintain runs fsck, remote prune, gc and commit-graph write in
+// each cloned Repo in rs, at most f.Jobs at a time, and prints
+// each Repo's size before and after with any corruption found.
+// Maintain skips the rest of VerifyRepos, but checks each
+// Repo's origin first, so that prune never runs against the
+// wrong remote. Maintain doesn't prompt, so it can run from
+// cron, and returns the number of Repos that are unverified,
+// corrupt or where a task failed.
+func (rs Repos) Maintain(ctx context.Context, f flags.Flags, st *stat.Stat, ti *timer.Timer) int {
+
+	rs.cloneSchedule(f, st) // verify cloned repos
+
+	ms := make([]repo.Maintenance, len(rs))
+	ix := make(map[*repo.Repo]int)
+
+	for i, r := range rs {
+		ix[r] = i
+	}
+
+	rs.each(f, func(r *repo.Repo) {
+		if !r.PendingClone {
+			r.GitConfigOriginURL(ctx) // check origin before prune
+			ms[ix[r]] = r.GitMaintain(ctx, f)
+		}
+	})
+
+	ti.Mark("maintain") // mark maintain
+
+	var n int
+	var before, after int64
+	var failed []string
+
+	for i, r := range rs {
+		m := ms[i]
+
+		if r.PendingClone {
+			continue
+		}
+
+		n++
+
+		if !r.Verified {
+			ew := emoji.Get("Warning")                                             // Warning emoji
+			flags.Printv(f, "%v %v not verified (%v)", ew, r.Name, r.ErrorMessage) // print error
+			failed = append(failed, r.Name)
+			continue
+		}
+
+		before += m.Before
+		after += m.After
+
+		ew := emoji.Get("Wrench")                  // Wrench emoji
+		flags.Printv(f, "%v %v %v", ew, r.Name, m) // print sizes
+
+		if len(m.Corrupt) >= 1 {
+			es := emoji.Get("Stop")                                                      // Stop emoji
+			cs := strings.Join(m.Corrupt, "; ")                                          // short corruption
+			flags.Printv(f, "%v %v fsck found [%v](%v)", es, r.Name, len(m.Corrupt), cs) // print corruption
+		}
+
+		if len(m.Failed) >= 1 {
+			ew := emoji.Get("Warning")                                            // Warning emoji
+			flags.Printv(f, "%v %v %v", ew, r.Name, strings.Join(m.Failed, ", ")) // print failures
+		}
+
+		if len(m.Corrupt) >= 1 || len(m.Failed) >= 1 {
+			failed = append(failed, r.Name)
+		}
+	}
+
+	ts := ti.Split()                                     // last split
+	tt := ti.Elapsed()                                   // elapsed time
+	sz := repo.Maintenance{Before: before, After: after} // total sizes
+
+	if len(failed) == 0 {
+		et := emoji.Get("ThumbsUp")                                           // ThumbsUp emoji
+		flags.Printv(f, "%v maintained [%v] %v {%v / %v}", et, n, sz, ts, tt) // print summary
+		return 0
+	}
+
+	fs := brf.Summary(failed, 25)                                                                // short summary
+	es := emoji.Get("Stop")                                                                      // Stop emoji
+	flags.Printv(f, "%v failed in [%v/%v](%v) %v {%v / %v}", es, len(failed), n, fs, sz, ts, tt) // print summary
+	return len(failed)
+}
@@ -0,0 +1,149 @@
+package repos
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/jychri/brf"
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+)
+
+// private
+
+// refArgs returns the name, message and whether to delete from
+// the args of "gis branch" and "gis tag", "-d v1.2.0" or
+// "v1.2.0 Release 1.2.0". The message defaults to the name.
+func refArgs(f flags.Flags) (name string, m string, del bool) {
+	as := f.Args
+
+	if len(as) >= 1 && as[0] == "-d" {
+		del, as = true, as[1:]
+	}
+
+	if len(as) == 0 {
+		log.Fatalf("%v: no name, try 'gis %v v1.2.0' or 'gis %v -d v1.2.0'", f.Command, f.Command, f.Command)
+	}
+
+	name, m = as[0], strings.Join(as[1:], " ")
+
+	if !repo.ValidRef(f.Command, name) {
+		log.Fatalf("%v: invalid name '%v'", f.Command, name)
+	}
+
+	if m == "" {
+		m = name
+	}
+
+	return name, m, del
+}
+
+// refPreflight prints the Repos in rs that can't take the change,
+// returning false if there are any.
+func (rs Repos) refPreflight(ctx context.Context, f flags.Flags, name string, del bool) bool {
+	var mu sync.Mutex
+	ok := true
+
+	rs.each(f, func(r *repo.Repo) {
+		var reason string
+
+		switch {
+		case del && !r.Verified:
+			reason = "not verified"
+		case del && f.Command == "branch" && r.LocalBranch == name:
+			reason = "on " + name
+		case !del:
+			reason = r.Preflight(ctx, f, f.Command, name)
+		}
+
+		if reason == "" {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		ew := emoji.Get("Warning")                      // Warning emoji
+		flags.Printv(f, "%v %v %v", ew, r.Name, reason) // print reason
+		ok = false
+	})
+
+	return ok
+}
+
+// Public
+
+// Refs creates or deletes the branch or tag named in f.Args in
+// every Repo in rs, pushing the change to origin. Before touching
+// any Repo, Refs checks that each is Complete, on its expected
+// branch and without name on origin for creation, or verified
+// and not on the branch for deletion, and asks for
+// confirmation. Refs returns the number of Repos that failed,
+// or all of them if the preflight fails.
+func (rs Repos) Refs(ctx context.Context, f flags.Flags, ti *timer.Timer) int {
+
+	name, m, del := refArgs(f)
+	verb := "create"
+
+	if del {
+		verb = "delete"
+	}
+
+	if !rs.refPreflight(ctx, f, name, del) {
+		es := emoji.Get("Stop")                                                          // Stop emoji
+		flags.Printv(f, "%v preflight failed, %v %v not %vd", es, f.Command, name, verb) // print
+		return len(rs)
+	}
+
+	ef := emoji.Get("Fork")                                    // Fork emoji
+	ns := brf.Summary(rs.names(), 25)                          // short summary
+	q := "%v %v %v %v in [%v](%v)? "                           // question
+	a := []interface{}{ef, verb, f.Command, name, len(rs), ns} // arguments
+
+	if !f.Logout() && !flags.Confirm(f, q, a...) {
+		return len(rs)
+	}
+
+	var mu sync.Mutex
+	var failed []string
+
+	rs.each(f, func(r *repo.Repo) {
+		var ok bool
+
+		if del {
+			ok = r.GitDeleteRef(ctx, f, f.Command, name)
+		} else {
+			ok = r.GitCreateRef(ctx, f, f.Command, name, m)
+		}
+
+		if ok {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		ew := emoji.Get("Warning")                                // Warning emoji
+		flags.Printv(f, "%v %v (%v)", ew, r.Name, r.ErrorMessage) // print error
+		failed = append(failed, r.Name)
+	})
+
+	ti.Mark("refs") // mark refs
+
+	ts := ti.Split()   // last split
+	tt := ti.Elapsed() // elapsed time
+
+	if len(failed) == 0 {
+		et := emoji.Get("ThumbsUp")                                                                   // ThumbsUp emoji
+		flags.Printv(f, "%v %vd %v %v in [%v] {%v / %v}", et, verb, f.Command, name, len(rs), ts, tt) // print summary
+		return 0
+	}
+
+	fs := brf.Summary(failed, 25)                                                               // short summary
+	es := emoji.Get("Stop")                                                                     // Stop emoji
+	flags.Printv(f, "%v failed in [%v/%v](%v) {%v / %v}", es, len(failed), len(rs), fs, ts, tt) // print summary
+	return len(failed)
+}
@@ -3,10 +3,13 @@ package repos
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/jychri/brf"
 	"github.com/jychri/timer"
@@ -69,6 +72,47 @@ func (rs Repos) byWorkspacePath() {
 	sort.SliceStable(rs, func(i, j int) bool { return rs[i].WorkspacePath < rs[j].WorkspacePath })
 }
 
+// each runs fn for each Repo in rs concurrently, at most
+// f.Jobs at a time, or all at once if f.Jobs is 0.
+func (rs Repos) each(f flags.Flags, fn func(r *repo.Repo)) {
+	var wg sync.WaitGroup
+	var sem chan struct{}
+
+	if f.Jobs >= 1 {
+		sem = make(chan struct{}, f.Jobs)
+	}
+
+	for i := range rs {
+		wg.Add(1)
+		go func(r *repo.Repo) {
+			defer wg.Done()
+
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+
+			fn(r)
+		}(rs[i])
+	}
+	wg.Wait()
+}
+
+// filter returns the Repos in rs selected by the -w and -r flags.
+func (rs Repos) filter(f flags.Flags) (frs Repos) {
+	for _, r := range rs {
+		if f.Selected(r.Workspace, r.Name) {
+			frs = append(frs, r)
+		}
+	}
+
+	if len(frs) == 0 {
+		log.Fatalf("No repos match -w %v -r %v", f.Workspaces, f.Repos)
+	}
+
+	return frs
+}
+
 // print startup
 func initPrint(f flags.Flags) {
 	ep := emoji.Get("Pager") // Pager emoji
@@ -79,8 +123,45 @@ func initPrint(f flags.Flags) {
 func initConvert(c conf.Config) (rs Repos) {
 	for _, bl := range c.Bundles {
 		for _, z := range bl.Zones {
-			for _, rn := range z.Repos {
-				r := repo.Init(z.Workspace, z.User, z.Remote, bl.Path, rn)
+			for _, rc := range z.Repos {
+				r := repo.Init(z.Workspace, z.User, z.Remote, bl.Path, rc.Name)
+				r.Pull = conf.Pick(rc.Pull, z.Pull, c.Pull, r.Pull)
+				r.Branch = rc.Branch
+				r.Origin = conf.Pick(rc.Origin, z.Origin, c.Origin, r.Origin)
+				r.Recurse = conf.Pick(rc.Submodules, z.Submodules, c.Submodules) == "recurse"
+				r.Template = conf.Pick(z.Message, c.Message)
+				r.Hooks = conf.Hooks(rc.Hooks, z.Hooks, c.Hooks)
+				r.Conventional = conf.Pick(z.Convention, c.Convention) == "conventional"
+
+				if a := z.Author; a != nil || c.Author != nil {
+					if a == nil {
+						a = c.Author
+					}
+					r.AuthorName = a.Name
+					r.AuthorEmail = a.Email
+					r.SigningKey = a.SigningKey
+					r.SigningFormat = a.Format
+				}
+
+				if c.Scan != nil {
+					r.ScanPatterns = c.Scan.Patterns
+				}
+
+				if c.Scan != nil && c.Scan.MaxSize >= 1 {
+					r.MaxSize = int64(c.Scan.MaxSize) << 20
+				}
+
+				if cl := rc.Clone; cl != nil || z.Clone != nil {
+					if cl == nil {
+						cl = z.Clone
+					}
+					r.Depth = cl.Depth
+					r.SingleBranch = cl.SingleBranch
+					r.Filter = cl.Filter
+					r.Sparse = cl.Sparse
+				}
+
+				r.SetRemotes(rc.Remotes)
 				rs = append(rs, r)
 			}
 		}
@@ -162,7 +243,7 @@ func (rs Repos) cloneSchedule(f flags.Flags, st *stat.Stat) {
 }
 
 // clone missing repos (async)
-func (rs Repos) cloneAsync(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
+func (rs Repos) cloneAsync(ctx context.Context, f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 
 	// return early if no pending clones
 	if len(st.PendingClones) == 0 {
@@ -174,27 +255,68 @@ func (rs Repos) cloneAsync(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 	ps := brf.Summary(st.PendingClones, 25) // short summary
 	flags.Printv(f, "%v cloning [%v](%v)", es, pc, ps)
 
-	var wg sync.WaitGroup
-	for i := range rs {
-		wg.Add(1)
-		go func(r *repo.Repo) {
-			defer wg.Done()
-			r.GitClone(f)
-		}(rs[i])
-	}
-	wg.Wait()
+	done := make(chan struct{})
+	go rs.cloneProgress(f, done) // redraw progress until done
+
+	rs.each(f, func(r *repo.Repo) {
+		if r.GitClone(ctx, f); r.Cloned {
+			r.Hook(ctx, f, "post-clone")
+		}
+	})
+	close(done)
 
 	ti.Mark("async-clone") // mark async-clone
 }
 
+// redraw the progress of running clones on one line until done
+func (rs Repos) cloneProgress(f flags.Flags, done chan struct{}) {
+	tk := time.NewTicker(250 * time.Millisecond)
+	defer tk.Stop()
+
+	eb := emoji.Get("Box") // Box emoji
+
+	for {
+		select {
+		case <-done:
+			flags.Printr(f, "")
+			return
+		case <-tk.C:
+		}
+
+		var ss []string
+
+		for _, r := range rs {
+			if p := r.Progress(); p != "" {
+				ss = append(ss, fmt.Sprintf("%v (%v)", r.Name, p))
+			}
+		}
+
+		if len(ss) >= 1 {
+			flags.Printr(f, "%v [%v] %v", eb, len(ss), strings.Join(ss, ", "))
+		}
+	}
+}
+
 // print summary
 func (rs Repos) cloneSummary(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 
-	// loop over repos again, record clone count in Stat.
+	var rr []string
+
+	// loop over repos again, record clone count in Stat
+	// and clones that were retried, "brf 2"
 	for _, r := range rs {
 		if r.Cloned == true {
 			st.ClonedRepos = append(st.ClonedRepos, r.Name)
 		}
+
+		if r.CloneAttempts >= 2 {
+			rr = append(rr, fmt.Sprintf("%v %v", r.Name, r.CloneAttempts))
+		}
+	}
+
+	if len(rr) >= 1 {
+		eh := emoji.Get("Hourglass") // Hourglass emoji
+		flags.Printv(f, "%v [%v] clones retried (%v)", eh, len(rr), strings.Join(rr, ", "))
 	}
 
 	// return if nothing was cloned
@@ -219,32 +341,84 @@ func (rs Repos) infoPrint(f flags.Flags, st *stat.Stat) {
 	flags.Printv(f, "%v  checking repos [%v](%v)", ep, lr, sr)
 }
 
-func (rs Repos) infoAsync(f flags.Flags, ti *timer.Timer) {
+// info updates Git information and sets the status of r.
+func info(ctx context.Context, f flags.Flags, r *repo.Repo) {
+	r.GitConfigOriginURL(ctx)
+	r.GitAddRemotes(ctx)
+	r.GitRemoteUpdate(ctx, f)
+	r.GitAbbrevRef(ctx)
+	r.GitDefaultBranch(ctx)
+	r.GitLag(ctx)
+	r.GitLocalSHA(ctx)
+	r.GitUpstreamBranch(ctx)
+	r.GitMergeBaseSHA(ctx, f)
+	r.GitRevParseUpstream(ctx)
+	r.GitDiffsNameOnly(ctx)
+	r.GitShortstat(ctx)
+	r.GitUntracked(ctx)
+	r.GitSubmodules(ctx)
+	r.GitLFS(ctx)
+	r.GitScan(ctx)
+	r.GitIdentity(ctx)
+	r.GitStashes(ctx)
+	r.SetStatus(f)
+}
 
-	var wg sync.WaitGroup
+func (rs Repos) infoAsync(ctx context.Context, f flags.Flags, ti *timer.Timer) {
 
-	for i := range rs {
-		wg.Add(1)
-		go func(r *repo.Repo) {
-			defer wg.Done()
-			r.GitConfigOriginURL()
-			r.GitRemoteUpdate()
-			r.GitAbbrevRef()
-			r.GitLocalSHA()
-			r.GitUpstreamBranch()
-			r.GitMergeBaseSHA()
-			r.GitRevParseUpstream()
-			r.GitDiffsNameOnly()
-			r.GitShortstat()
-			r.GitUntracked()
-			r.SetStatus(f)
-		}(rs[i])
-	}
-	wg.Wait()
+	rs.each(f, func(r *repo.Repo) {
+		r.GitClear()
+		info(ctx, f, r)
+	})
 
 	ti.Mark("info-async") // mark info-async
 }
 
+// authRetry offers to re-run fn one repo at a time in the
+// foreground for repos that failed to authenticate in the
+// background, so that the user can enter credentials. Repos
+// are only offered once, whether or not the retry succeeds.
+func (rs Repos) authRetry(ctx context.Context, f flags.Flags, fn func(r *repo.Repo)) {
+
+	var ars Repos
+
+	for _, r := range rs {
+		if r.AuthRequired {
+			ars = append(ars, r)
+		}
+	}
+
+	if len(ars) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	defer func() {
+		for _, r := range ars {
+			r.AuthRequired = false
+		}
+	}()
+
+	ek := emoji.Get("Key")             // Key emoji
+	la := len(ars)                     // number of repos
+	sa := brf.Summary(ars.names(), 25) // short summary
+
+	if !flags.Confirm(f, "%v [%v](%v) need credentials, retry in the foreground? ", ek, la, sa) {
+		return
+	}
+
+	for _, r := range ars {
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		flags.Printv(f, "%v %v", ek, r.Name)
+		r.Foreground = true
+		fn(r)
+		r.Foreground = false
+	}
+}
+
 func (rs Repos) changesSummary(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 	rs.category(st)              // set current category stats
 	tr := len(st.Repos)          // number of repos
@@ -287,65 +461,303 @@ func (rs Repos) infoSummary(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 	case st.CheckSkipped():
 		flags.Printv(f, "%v [%v/%v] repos complete {%v / %v}", es, cr, tr, ts, tt)
 	}
+
+	if sr := st.StashedRepos; len(sr) >= 1 {
+		ef := emoji.Get("FileCabinet")                                                       // FileCabinet emoji
+		flags.Printv(f, "%v [%v] repos with stashes (%v)", ef, len(sr), brf.Summary(sr, 25)) // print stashes
+	}
 }
 
-func (rs Repos) promptUser(f flags.Flags, st *stat.Stat) {
+func (rs Repos) promptUser(ctx context.Context, f flags.Flags, st *stat.Stat) {
 	if st.CheckComplete() {
 		return
 	}
 
 	for _, r := range rs {
+		if ctx.Err() != nil {
+			return
+		}
 		r.UserConfirm(f)
 	}
 }
 
-func (rs Repos) changesAsync(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
+// act runs the steps of r.Action, stopping at the first
+// step that fails. r's state is kept until infoAsync updates
+// it, so that a failed step can be retried.
+func act(ctx context.Context, f flags.Flags, r *repo.Repo) {
+	switch r.Action {
+	case "Pull":
+		pull(ctx, f, r, r.GitPull)
+	case "Push":
+		push(ctx, f, r, r.GitPush)
+	case "Add-Commit-Push":
+		if commit(ctx, f, r) {
+			push(ctx, f, r, r.GitPush)
+		}
+	case "Stash-Pull-Pop-Commit-Push":
+		if pull(ctx, f, r, r.GitStashPullPop) && commit(ctx, f, r) {
+			push(ctx, f, r, r.GitPush)
+		}
+	case "Initial-Commit-Push":
+		if commit(ctx, f, r) {
+			push(ctx, f, r, r.GitPushUpstream)
+		}
+	case "Upstream-Push":
+		push(ctx, f, r, r.GitPushUpstream)
+	case "Checkout":
+		r.GitCheckout(ctx, f)
+	}
+}
+
+// retry runs the steps of r.Action again from r.AuthStep, the
+// step that failed to authenticate. Failed pulls are rolled
+// back, so their actions run again from the start; pushes run
+// on their own, as their commits have already been made.
+func retry(ctx context.Context, f flags.Flags, r *repo.Repo) {
+	step := r.AuthStep
+	r.Reset()
+	r.Category = "Scheduled"
+
+	fn := r.GitPush
+
+	switch r.Action {
+	case "Initial-Commit-Push", "Upstream-Push":
+		fn = r.GitPushUpstream
+	}
+
+	switch step {
+	case "GitLFSPush", "GitPush", "GitPushUpstream":
+		push(ctx, f, r, fn)
+	default:
+		act(ctx, f, r)
+	}
+}
+
+// pull runs fn, a pull, with LFS, submodules and hooks.
+func pull(ctx context.Context, f flags.Flags, r *repo.Repo, fn func(context.Context, flags.Flags) bool) bool {
+	return r.Hook(ctx, f, "pre-pull") && fn(ctx, f) && r.GitLFSPull(ctx, f) &&
+		r.GitSubmoduleUpdate(ctx, f) && r.Hook(ctx, f, "post-pull")
+}
+
+// commit adds and commits with hooks.
+func commit(ctx context.Context, f flags.Flags, r *repo.Repo) bool {
+	return r.GitAdd(ctx, f) && r.Hook(ctx, f, "pre-commit") && r.GitCommit(ctx, f) &&
+		r.Hook(ctx, f, "post-commit")
+}
+
+// push runs fn, a push, with LFS and hooks.
+func push(ctx context.Context, f flags.Flags, r *repo.Repo, fn func(context.Context, flags.Flags) bool) bool {
+	return r.Hook(ctx, f, "pre-push") && r.GitLFSPush(ctx, f) && fn(ctx, f) &&
+		r.Hook(ctx, f, "post-push")
+}
+
+func (rs Repos) changesAsync(ctx context.Context, f flags.Flags, st *stat.Stat, ti *timer.Timer) {
 	if st.CheckComplete() {
 		return
 	}
 
-	var wg sync.WaitGroup
-	for i := range rs {
-		wg.Add(1)
-		go func(r *repo.Repo) {
-			defer wg.Done()
+	rs.each(f, func(r *repo.Repo) {
+		if r.Category != "Scheduled" {
+			return
+		}
 
-			if r.Category != "Scheduled" {
-				return
+		act(ctx, f, r)
+	})
+
+	st.Clear()
+}
+
+// list local branches (async)
+func (rs Repos) branchesAsync(ctx context.Context, f flags.Flags, ti *timer.Timer) {
+
+	rs.each(f, func(r *repo.Repo) {
+		r.GitBranches(ctx)
+	})
+
+	ti.Mark("branches-async") // mark branches-async
+}
+
+// originRepair repairs repos whose origin URL doesn't match
+// gisrc.json, by r.Origin or on confirmation, rewriting either
+// the checkout's remote.origin.url or gisrc.json, then updates
+// their info.
+func (rs Repos) originRepair(ctx context.Context, f flags.Flags) {
+	ew := emoji.Get("Wrench") // Wrench emoji
+
+	for _, r := range rs {
+
+		if !r.Mismatch || ctx.Err() != nil {
+			continue
+		}
+
+		p := r.Origin
+
+		if p == "ask" {
+			q := "%v %v origin is %v, not %v: set [r]emote, update [c]onfig or skip? "
+			switch flags.Ask(f, q, ew, r.Name, r.OriginURL, r.URL) {
+			case "r", "remote":
+				p = "remote"
+			case "c", "config":
+				p = "config"
 			}
+		}
 
-			switch r.Action {
-			case "Pull":
-				r.GitPull(f)
-				r.GitClear()
-			case "Push":
-				r.GitPush(f)
-				r.GitClear()
-			case "Add-Commit-Push":
-				r.GitAdd(f)
-				r.GitCommit(f)
-				r.GitPush(f)
-				r.GitClear()
-			case "Stash-Pull-Pop-Commit-Push":
-				r.GitAdd(f)
-				r.GitStash(f)
-				r.GitPull(f)
-				r.GitPop(f)
-				r.GitAdd(f)
-				r.GitCommit(f)
-				r.GitPush(f)
-				r.GitClear()
+		switch p {
+		case "remote":
+			r.Reset()
+			if !r.GitSetOriginURL(ctx, f) {
+				continue
 			}
-		}(rs[i])
+			flags.Printv(f, "%v %v checkout changed, origin is %v", ew, r.Name, r.URL)
+		case "config":
+			if err := conf.SetOrigin(f, r.BundlePath, r.Workspace, r.Name, r.OriginURL); err != nil {
+				flags.Printv(f, "%v %v %v", ew, r.Name, err)
+				continue
+			}
+			r.URL = r.OriginURL
+			flags.Printv(f, "%v %v %v changed, origin is %v", ew, r.Name, f.Config, r.URL)
+		default:
+			continue
+		}
+
+		r.Reset()
+		r.GitClear()
+		info(ctx, f, r)
 	}
-	wg.Wait()
+}
 
-	st.Clear()
+// print repos whose origin lags behind another remote
+func (rs Repos) remotesPrint(f flags.Flags) {
+	ef := emoji.Get("Fork") // Fork emoji
+
+	for _, r := range rs {
+		var ss []string
+
+		for _, rm := range r.Lagging() {
+			ss = append(ss, rm.String())
+		}
+
+		if len(ss) >= 1 {
+			flags.Printv(f, "%v %v %v (%v)", ef, r.Name, r.DefaultBranch, strings.Join(ss, ", "))
+		}
+	}
+}
+
+// print submodules that are uninitialized, out of date,
+// conflicted or dirty
+func (rs Repos) submodulesPrint(f flags.Flags) {
+	eb := emoji.Get("Box") // Box emoji
+
+	for _, r := range rs {
+		var ss []string
+
+		for _, s := range r.Unsynced() {
+			ss = append(ss, s.String())
+		}
+
+		if len(ss) >= 1 {
+			flags.Printv(f, "%v %v [%v](%v)", eb, r.Name, len(ss), strings.Join(ss, ", "))
+		}
+	}
+}
+
+// print repos whose identity differs from their zone's
+func (rs Repos) identityPrint(f flags.Flags) {
+	eb := emoji.Get("Bust") // Bust emoji
+
+	for _, r := range rs {
+		if r.IdentityMismatch {
+			ri, zi := r.Identities()
+			flags.Printv(f, "%v %v commits as %v, %v expects %v", eb, r.Name, ri, r.Workspace, zi)
+		}
+	}
+}
+
+// print repos whose commits are blocked by secrets or large files
+func (rs Repos) scanPrint(f flags.Flags) {
+	es := emoji.Get("Stop") // Stop emoji
+
+	for _, r := range rs {
+		if strings.Contains(r.Action, "Commit") && r.Blocked() {
+			flags.Printv(f, "%v %v commit blocked, %v", es, r.Name, r.Hits())
+		}
+	}
+}
+
+// print files whose LFS objects are missing
+func (rs Repos) lfsPrint(f flags.Flags) {
+	ef := emoji.Get("Floppy") // Floppy emoji
+
+	for _, r := range rs {
+		if lm := len(r.LFSMissing); lm >= 1 {
+			sm := brf.Summary(r.LFSMissing, 25) // summary of missing files
+			flags.Printv(f, "%v %v missing LFS objects [%v](%v)", ef, r.Name, lm, sm)
+		}
+	}
+}
+
+// print branches that are ahead, behind or gone
+func (rs Repos) branchesPrint(f flags.Flags) {
+	eh := emoji.Get("Herb") // Herb emoji
+
+	for _, r := range rs {
+		var ss []string
+
+		for _, b := range r.Stray() {
+			ss = append(ss, b.String())
+		}
+
+		if len(ss) >= 1 {
+			flags.Printv(f, "%v %v [%v](%v)", eh, r.Name, len(ss), strings.Join(ss, ", "))
+		}
+	}
+}
+
+// fast-forward branches that are only behind (async)
+func (rs Repos) branchesSync(ctx context.Context, f flags.Flags, ti *timer.Timer) {
+
+	rs.each(f, func(r *repo.Repo) {
+		for _, b := range r.Stray() {
+			if b.Behind >= 1 && b.Ahead == 0 && !b.Gone {
+				r.GitFastForward(ctx, f, b)
+			}
+		}
+	})
+
+	ti.Mark("branches-sync") // mark branches-sync
+}
+
+// push branches that are only ahead, on confirmation
+func (rs Repos) branchesPush(ctx context.Context, f flags.Flags) {
+	for _, r := range rs {
+		for _, b := range r.Stray() {
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if b.Ahead == 0 || b.Behind >= 1 || b.Gone {
+				continue
+			}
+
+			er := emoji.Get("Rocket") // Rocket emoji
+
+			if f.Logout() || flags.Confirm(f, "%v push %v %v to %v? ", er, r.Name, b, b.Remote) {
+				r.GitPushBranch(ctx, f, b)
+			}
+		}
+	}
 }
 
 // update stat.Stat, collect Repos by category
 func (rs Repos) category(st *stat.Stat) {
+	st.StashedRepos = nil
+
 	for _, r := range rs {
+		if len(r.Stashes) >= 1 {
+			st.StashedRepos = append(st.StashedRepos, r.Name)
+		}
+
 		switch {
 		case r.Category == "Pending":
 			st.PendingRepos = append(st.PendingRepos, r.Name)
@@ -369,7 +781,7 @@ type Repos []*repo.Repo
 // Init returns a slice of Repo structs.
 func Init(c conf.Config, f flags.Flags, st *stat.Stat, ti *timer.Timer) Repos {
 	initPrint(f)                    // print startup
-	rs := initConvert(c)            // convert Config to Repos
+	rs := initConvert(c).filter(f)  // convert Config to Repos, filter
 	st.Workspaces = rs.workspaces() // record stats
 	ti.Mark("init-repos")           // mark timer
 	initSummary(f, st, ti, rs)      // print summary
@@ -383,19 +795,112 @@ func (rs Repos) VerifyWorkspaces(f flags.Flags, st *stat.Stat, ti *timer.Timer)
 }
 
 // VerifyRepos verifies all Repos in Repos.
-func (rs Repos) VerifyRepos(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
-	rs.cloneSchedule(f, st)    // schedule pending clones
-	rs.cloneAsync(f, st, ti)   // clone missing repos (async)
+func (rs Repos) VerifyRepos(ctx context.Context, f flags.Flags, st *stat.Stat, ti *timer.Timer) {
+	rs.cloneSchedule(f, st)       // schedule pending clones
+	rs.cloneAsync(ctx, f, st, ti) // clone missing repos (async)
+
+	// retry clones that need credentials
+	rs.authRetry(ctx, f, func(r *repo.Repo) {
+		r.Reset()
+		r.GitClone(ctx, f)
+	})
+
 	rs.cloneSummary(f, st, ti) // print summary
 	rs.infoPrint(f, st)        // print startup
-	rs.infoAsync(f, ti)        // update info (async)
-	rs.infoSummary(f, st, ti)  // print summary
+	rs.infoAsync(ctx, f, ti)   // update info (async)
+
+	// retry fetches that need credentials
+	rs.authRetry(ctx, f, func(r *repo.Repo) {
+		r.Reset()
+		r.GitClear()
+		info(ctx, f, r)
+	})
+
+	rs.originRepair(ctx, f)   // repair mismatched origins
+	rs.infoSummary(f, st, ti) // print summary
+	rs.remotesPrint(f)        // print forks that lag
+	rs.submodulesPrint(f)     // print stale submodules
+	rs.lfsPrint(f)            // print missing LFS objects
+	rs.scanPrint(f)           // print blocked commits
+	rs.identityPrint(f)       // print mismatched identities
 }
 
 // VerifyChanges ...
-func (rs Repos) VerifyChanges(f flags.Flags, st *stat.Stat, ti *timer.Timer) {
-	rs.promptUser(f, st)         // prompt user
-	rs.changesAsync(f, st, ti)   // submit changes (async)
-	rs.infoAsync(f, ti)          // update info (async)
+func (rs Repos) VerifyChanges(ctx context.Context, f flags.Flags, st *stat.Stat, ti *timer.Timer) {
+	rs.promptUser(ctx, f, st) // prompt user
+
+	for _, r := range rs {
+		r.AuthRequired = false // forget failures from verification
+		r.AuthStep = ""        //
+	}
+
+	rs.changesAsync(ctx, f, st, ti) // submit changes (async)
+
+	var ars Repos
+
+	for _, r := range rs {
+		if r.AuthStep != "" {
+			ars = append(ars, r)
+		}
+	}
+
+	// retry pulls and pushes that need credentials
+	ars.authRetry(ctx, f, func(r *repo.Repo) {
+		retry(ctx, f, r)
+	})
+
+	rs.infoAsync(ctx, f, ti)     // update info (async)
 	rs.changesSummary(f, st, ti) // update info (async)
 }
+
+// VerifyBranches reports every local branch with an upstream
+// that's ahead, behind or gone, fast-forwards branches that are
+// behind and, on confirmation, pushes branches that are ahead.
+// The checked out branch is left to VerifyChanges.
+func (rs Repos) VerifyBranches(ctx context.Context, f flags.Flags, ti *timer.Timer) {
+
+	if !f.Branches {
+		return
+	}
+
+	rs.branchesAsync(ctx, f, ti) // list local branches (async)
+	rs.branchesPrint(f)          // print summary
+	rs.branchesSync(ctx, f, ti)  // fast-forward behind branches (async)
+	rs.branchesPush(ctx, f)      // push ahead branches
+}
+
+// Canceled returns true if ctx has been canceled, printing
+// a summary of the repos that finished before the interrupt.
+func (rs Repos) Canceled(ctx context.Context, f flags.Flags, ti *timer.Timer) bool {
+
+	if ctx.Err() == nil {
+		return false
+	}
+
+	var cr, cl, cx []string
+
+	for _, r := range rs {
+		switch {
+		case r.Canceled():
+			cx = append(cx, r.Name)
+		case r.Category == "Complete":
+			cr = append(cr, r.Name)
+		}
+
+		if r.Cloned {
+			cl = append(cl, r.Name)
+		}
+	}
+
+	es := emoji.Get("Slash")  // Slash emoji
+	tr := len(rs)             // number of repos
+	lc := len(cr)             // number of complete repos
+	ll := len(cl)             // number of cloned repos
+	lx := len(cx)             // number of canceled repos
+	sx := brf.Summary(cx, 25) // summary of canceled repos
+	ts := ti.Split()          // last split
+	tt := ti.Elapsed()        // elapsed time
+	flags.Printv(f, "%v canceled: [%v/%v] repos complete, [%v] cloned, [%v] interrupted (%v) {%v / %v}", es, lc, tr, ll, lx, sx, ts, tt)
+
+	return true
+}
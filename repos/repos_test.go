@@ -1,7 +1,10 @@
 package repos
 
 import (
+	"context"
 	"os"
+	"os/exec"
+	"path"
 	"strings"
 	"testing"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/jychri/git-in-sync/atp"
 	"github.com/jychri/git-in-sync/conf"
 	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
 	"github.com/jychri/git-in-sync/stat"
 )
 
@@ -57,7 +61,7 @@ func TestVerifyRepos(t *testing.T) {
 		defer cleanup()
 
 		rs.VerifyWorkspaces(f, st, ti)
-		rs.VerifyRepos(f, st, ti)
+		rs.VerifyRepos(context.Background(), f, st, ti)
 
 		for _, r := range rs {
 
@@ -89,7 +93,7 @@ func TestVerifyChanges(t *testing.T) {
 		defer cleanup()
 
 		rs.VerifyWorkspaces(f, st, ti)
-		rs.VerifyRepos(f, st, ti)
+		rs.VerifyRepos(context.Background(), f, st, ti)
 
 		for _, r := range rs {
 
@@ -101,8 +105,8 @@ func TestVerifyChanges(t *testing.T) {
 			r.Message = "'TESTVERIFY' commit"
 		}
 
-		rs.changesAsync(f, st, ti)
-		rs.infoAsync(f, ti)
+		rs.changesAsync(context.Background(), f, st, ti)
+		rs.infoAsync(context.Background(), f, ti)
 
 		for _, r := range rs {
 			if r.Status != "Complete" {
@@ -111,3 +115,70 @@ func TestVerifyChanges(t *testing.T) {
 		}
 	}
 }
+
+func TestCommand(t *testing.T) {
+	for _, tr := range []struct {
+		as   []string
+		want string
+	}{
+		{[]string{"make && make test"}, "make && make test"},
+		{[]string{"grep", "-n", "foo bar"}, "grep -n 'foo bar'"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+	} {
+		if got := command(tr.as); got != tr.want {
+			t.Errorf("command: %q != %q", got, tr.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+
+	dir, cleanup := atp.Local("repos-retry", "gis-Complete")
+	defer cleanup()
+
+	ctx := context.Background()
+	f := flags.Testing("~/fakegisrc.json")
+	r := repo.Init("tmpgis", "local", "github", dir, "gis-Complete")
+	r.Verified = true
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", r.RepoPath}, args...)...).Output()
+
+		if err != nil {
+			t.Fatalf("git %v (%v)", args, err)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+
+	url := git("remote", "get-url", "origin")
+	r.URL = url
+	git("branch", "--unset-upstream")
+	info(ctx, f, r)
+
+	if r.Action != "Upstream-Push" {
+		t.Fatalf("retry: %v %v (%v %v)", r.Status, r.Action, r.ErrorName, r.ErrorMessage)
+	}
+
+	// fail the push, as if origin had asked for credentials
+	git("remote", "set-url", "origin", path.Join(dir, "missing.git"))
+	r.Category = "Scheduled"
+	act(ctx, f, r)
+
+	if r.ErrorName != "GitPushUpstream" {
+		t.Fatalf("retry: %v (%v)", r.ErrorName, r.ErrorMessage)
+	}
+
+	git("remote", "set-url", "origin", url)
+	r.AuthRequired = true
+	r.AuthStep = r.ErrorName
+	retry(ctx, f, r)
+
+	if !r.Verified || r.Category == "Skipped" {
+		t.Errorf("retry: %v (%v)", r.ErrorName, r.ErrorMessage)
+	}
+
+	if got := git("rev-parse", "--abbrev-ref", "@{u}"); got != "origin/master" {
+		t.Errorf("retry: upstream %q", got)
+	}
+}
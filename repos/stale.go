@@ -0,0 +1,96 @@
+package repos
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+)
+
+// Public
+
+// Stale lists the local branches in each Repo in rs that are
+// merged into the expected branch, whose upstream is gone or
+// that have had no commits for f.Days, and offers to delete
+// them one Repo at a time. Branches that are only old are
+// confirmed one at a time, as they may not be merged. Branches
+// are only listed in logout mode. Stale returns the number of
+// Repos where deletion failed.
+func (rs Repos) Stale(ctx context.Context, f flags.Flags, ti *timer.Timer) int {
+
+	rs.each(f, func(r *repo.Repo) {
+		r.GitStale(ctx, f.Days)
+	})
+
+	ti.Mark("stale") // mark stale
+
+	var n, failed int
+	eh := emoji.Get("Herb") // Herb emoji
+
+	for _, r := range rs {
+		var ss []string
+
+		for _, s := range r.StaleBranches {
+			ss = append(ss, s.String())
+		}
+
+		if len(ss) >= 1 {
+			flags.Printv(f, "%v %v [%v](%v)", eh, r.Name, len(ss), strings.Join(ss, ", "))
+			n += len(ss)
+		}
+	}
+
+	ts := ti.Split()   // last split
+	tt := ti.Elapsed() // elapsed time
+
+	if n == 0 {
+		et := emoji.Get("ThumbsUp")                                   // ThumbsUp emoji
+		flags.Printv(f, "%v no stale branches {%v / %v}", et, ts, tt) // print summary
+		return 0
+	}
+
+	if f.Logout() {
+		return 0
+	}
+
+	es := emoji.Get("Slash") // Slash emoji
+
+	for _, r := range rs {
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		var ss, old []repo.Stale
+
+		for _, s := range r.StaleBranches {
+			if s.AgeOnly() {
+				old = append(old, s)
+			} else {
+				ss = append(ss, s)
+			}
+		}
+
+		if len(ss) >= 1 && !flags.Confirm(f, "%v delete [%v] merged or gone branches in %v? ", es, len(ss), r.Name) {
+			ss = nil
+		}
+
+		for _, s := range old {
+			if flags.Confirm(f, "%v delete unmerged %v in %v? ", es, s, r.Name) {
+				ss = append(ss, s)
+			}
+		}
+
+		if !r.GitDeleteStale(ctx, f, ss) {
+			ew := emoji.Get("Warning")                                // Warning emoji
+			flags.Printv(f, "%v %v (%v)", ew, r.Name, r.ErrorMessage) // print error
+			failed++
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,145 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jychri/timer"
+
+	"github.com/jychri/git-in-sync/emoji"
+	"github.com/jychri/git-in-sync/flags"
+	"github.com/jychri/git-in-sync/repo"
+)
+
+// private
+
+// stashArgs returns the action and the stash entries selected
+// by name from the args of "gis stash", "drop gis-Ahead:0
+// gis-Ahead:2". The action is "list" without args.
+func (rs Repos) stashArgs(f flags.Flags) (action string, sel map[*repo.Repo][]int) {
+
+	if len(f.Args) == 0 {
+		return "list", nil
+	}
+
+	action = f.Args[0]
+
+	switch action {
+	case "list", "show", "pop", "drop":
+	default:
+		log.Fatalf("stash: unknown action '%v', try list, show, pop or drop", action)
+	}
+
+	if action != "list" && len(f.Args) == 1 {
+		log.Fatalf("stash: no entries, try 'gis stash %v gis-Ahead:0'", action)
+	}
+
+	sel = make(map[*repo.Repo][]int)
+
+	for _, a := range f.Args[1:] {
+		i := strings.LastIndex(a, ":")
+
+		if i < 0 {
+			log.Fatalf("stash: '%v' isn't repo:entry, 'gis-Ahead:0'", a)
+		}
+
+		r := rs.direct(a[:i])
+		n, err := strconv.Atoi(a[i+1:])
+
+		switch {
+		case r == nil:
+			log.Fatalf("stash: no repo '%v'", a[:i])
+		case err != nil || n < 0 || n >= len(r.Stashes):
+			log.Fatalf("stash: %v has no entry '%v'", r.Name, a[i+1:])
+		}
+
+		if !selected(sel[r], n) {
+			sel[r] = append(sel[r], n)
+		}
+	}
+
+	return action, sel
+}
+
+// selected returns true if is contains i.
+func selected(is []int, i int) bool {
+	for _, e := range is {
+		if e == i {
+			return true
+		}
+	}
+	return false
+}
+
+// Public
+
+// Stashes lists every stash entry in each Repo in rs with its
+// age and message, or shows, pops or drops the entries selected
+// in f.Args after confirmation. Stashes returns the number of
+// Repos where an action failed.
+func (rs Repos) Stashes(ctx context.Context, f flags.Flags, ti *timer.Timer) int {
+
+	action, sel := rs.stashArgs(f)
+	ef := emoji.Get("FileCabinet") // FileCabinet emoji
+
+	switch action {
+	case "list":
+		var n int
+
+		for _, r := range rs {
+			for _, s := range r.Stashes {
+				flags.Printv(f, "%v %v %v", ef, r.Name, s)
+				n++
+			}
+		}
+
+		ts := ti.Split()                                            // last split
+		tt := ti.Elapsed()                                          // elapsed time
+		flags.Printv(f, "%v [%v] stashes {%v / %v}", ef, n, ts, tt) // print summary
+		return 0
+	case "show":
+		var failed int
+
+		for _, r := range rs {
+			for _, i := range sel[r] {
+				flags.Printv(f, "%v %v %v", ef, r.Name, r.Stashes[i])
+
+				out, ok := r.GitStashShow(ctx, i)
+
+				if !ok {
+					failed++
+					break
+				}
+
+				fmt.Println(out)
+			}
+		}
+
+		return failed
+	}
+
+	var n int
+
+	for _, is := range sel {
+		n += len(is)
+	}
+
+	if !f.Logout() && !flags.Confirm(f, "%v %v [%v] stashes in [%v] repos? ", ef, action, n, len(sel)) {
+		return 0
+	}
+
+	var failed int
+
+	for _, r := range rs {
+		if is, ok := sel[r]; ok && !r.GitStashApply(ctx, f, action, is) {
+			ew := emoji.Get("Warning")                                // Warning emoji
+			flags.Printv(f, "%v %v (%v)", ew, r.Name, r.ErrorMessage) // print error
+			failed++
+		}
+	}
+
+	return failed
+}
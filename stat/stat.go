@@ -20,6 +20,7 @@ type Stat struct {
 	CompleteRepos          []string
 	ScheduledPull          []string
 	ScheduledPush          []string
+	StashedRepos           []string
 }
 
 // Init returns a new *Stat.
@@ -50,6 +51,7 @@ func (st *Stat) Clear() {
 	st.CompleteRepos = nil
 	st.ScheduledPull = nil
 	st.ScheduledPush = nil
+	st.StashedRepos = nil
 }
 
 // CheckComplete ...